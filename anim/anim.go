@@ -0,0 +1,222 @@
+package anim
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	pm "github.com/Oaklight/Rayerson/primitives"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// Animation keys the camera and the objects of a scene over frames
+type Animation struct {
+	// Start and End are the first and last frames to render
+	Start, End int
+	// camera tracks, nil when not animated
+	pos, lookAt, fov *Track
+	objects          []objectTrack
+}
+
+// objectTrack moves object index of the world, its translation and its
+// scale are relative to the loaded scene
+type objectTrack struct {
+	index            int
+	translate, scale *Track
+}
+
+// animationFile is the JSON document read by Load, every key may give any
+// subset of the values
+type animationFile struct {
+	Start         *int            `json:"start"`
+	End           *int            `json:"end"`
+	Interpolation string          `json:"interpolation"`
+	Camera        []cameraKey     `json:"camera"`
+	Objects       []objectKeyList `json:"objects"`
+}
+
+type cameraKey struct {
+	Frame  float64     `json:"frame"`
+	Pos    *[3]float64 `json:"pos"`
+	LookAt *[3]float64 `json:"lookAt"`
+	FOV    *float64    `json:"fov"`
+}
+
+type objectKeyList struct {
+	Index int         `json:"index"`
+	Keys  []objectKey `json:"keys"`
+}
+
+type objectKey struct {
+	Frame     float64     `json:"frame"`
+	Translate *[3]float64 `json:"translate"`
+	Scale     *float64    `json:"scale"`
+}
+
+// Load reads the animation at filePath, frames without an explicit range
+// span every key
+func Load(filePath string) (*Animation, error) {
+	inReader, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer inReader.Close()
+
+	dec := json.NewDecoder(inReader)
+	dec.DisallowUnknownFields()
+	var doc animationFile
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+	a, err := doc.build()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+	return a, nil
+}
+
+func (doc *animationFile) build() (*Animation, error) {
+	interp, err := ParseInterpolation(doc.Interpolation)
+	if err != nil {
+		return nil, err
+	}
+	first, last := math.Inf(1), math.Inf(-1)
+	// track builds the track of the keys giving a value, nil without any
+	track := func(keys []Key) (*Track, error) {
+		if len(keys) == 0 {
+			return nil, nil
+		}
+		t, err := NewTrack(keys, interp)
+		if err != nil {
+			return nil, err
+		}
+		lo, hi := t.Range()
+		first, last = math.Min(first, lo), math.Max(last, hi)
+		return t, nil
+	}
+
+	a := &Animation{}
+	var pos, lookAt, fov []Key
+	for _, k := range doc.Camera {
+		if k.Pos != nil {
+			pos = append(pos, Key{k.Frame, k.Pos[:]})
+		}
+		if k.LookAt != nil {
+			lookAt = append(lookAt, Key{k.Frame, k.LookAt[:]})
+		}
+		if k.FOV != nil {
+			fov = append(fov, Key{k.Frame, []float64{*k.FOV}})
+		}
+	}
+	if a.pos, err = track(pos); err != nil {
+		return nil, fmt.Errorf("camera pos: %v", err)
+	}
+	if a.lookAt, err = track(lookAt); err != nil {
+		return nil, fmt.Errorf("camera lookAt: %v", err)
+	}
+	if a.fov, err = track(fov); err != nil {
+		return nil, fmt.Errorf("camera fov: %v", err)
+	}
+
+	seen := map[int]bool{}
+	for _, o := range doc.Objects {
+		if seen[o.Index] {
+			return nil, fmt.Errorf("object %d is keyed twice", o.Index)
+		}
+		seen[o.Index] = true
+		var translate, scale []Key
+		for _, k := range o.Keys {
+			if k.Translate != nil {
+				translate = append(translate, Key{k.Frame, k.Translate[:]})
+			}
+			if k.Scale != nil {
+				scale = append(scale, Key{k.Frame, []float64{*k.Scale}})
+			}
+		}
+		ot := objectTrack{index: o.Index}
+		if ot.translate, err = track(translate); err != nil {
+			return nil, fmt.Errorf("object %d translate: %v", o.Index, err)
+		}
+		if ot.scale, err = track(scale); err != nil {
+			return nil, fmt.Errorf("object %d scale: %v", o.Index, err)
+		}
+		a.objects = append(a.objects, ot)
+	}
+
+	if math.IsInf(first, 1) {
+		first, last = 0, 0
+	}
+	a.Start, a.End = int(math.Floor(first)), int(math.Ceil(last))
+	if doc.Start != nil {
+		a.Start = *doc.Start
+	}
+	if doc.End != nil {
+		a.End = *doc.End
+	}
+	if a.End < a.Start {
+		return nil, fmt.Errorf("end frame %d is before start frame %d", a.End, a.Start)
+	}
+	return a, nil
+}
+
+// Camera returns the camera position, look at point and field of view at
+// frame, the values which are not animated are returned unchanged
+func (a *Animation) Camera(frame float64, pos, lookAt vec3.Vec3, fov float64) (vec3.Vec3, vec3.Vec3, float64) {
+	toVec := func(v []float64) vec3.Vec3 {
+		return vec3.Vec3{X: v[0], Y: v[1], Z: v[2]}
+	}
+	if a.pos != nil {
+		pos = toVec(a.pos.At(frame))
+	}
+	if a.lookAt != nil {
+		lookAt = toVec(a.lookAt.At(frame))
+	}
+	if a.fov != nil {
+		fov = a.fov.At(frame)[0]
+	}
+	return pos, lookAt, fov
+}
+
+// Binding moves the objects of one world, remembering where they were
+// loaded so frames can be applied in any order
+type Binding struct {
+	anim    *Animation
+	spheres []*pm.Sphere
+	centers []vec3.Vec3
+	radii   []float64
+}
+
+// Bind checks every animated object of a exists in world
+func (a *Animation) Bind(world *pm.World) (*Binding, error) {
+	b := &Binding{anim: a}
+	for _, o := range a.objects {
+		if o.index < 0 || o.index >= len(*world) {
+			return nil, fmt.Errorf("object %d: the scene has %d objects", o.index, len(*world))
+		}
+		s, ok := (*world)[o.index].(*pm.Sphere)
+		if !ok {
+			return nil, fmt.Errorf("object %d: cannot move %T", o.index, (*world)[o.index])
+		}
+		b.spheres = append(b.spheres, s)
+		b.centers = append(b.centers, *s.Center)
+		b.radii = append(b.radii, s.Radius)
+	}
+	return b, nil
+}
+
+// Apply moves the bound objects where they are at frame
+func (b *Binding) Apply(frame float64) {
+	for i, o := range b.anim.objects {
+		center, radius := b.centers[i], b.radii[i]
+		if o.translate != nil {
+			t := o.translate.At(frame)
+			center = *center.Add(&vec3.Vec3{X: t[0], Y: t[1], Z: t[2]})
+		}
+		if o.scale != nil {
+			radius *= o.scale.At(frame)[0]
+		}
+		b.spheres[i].Center = &center
+		b.spheres[i].Radius = radius
+	}
+}
@@ -0,0 +1,113 @@
+package anim
+
+import (
+	"encoding/json"
+	"testing"
+
+	pm "github.com/Oaklight/Rayerson/primitives"
+	"github.com/Oaklight/Rayerson/ray"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// parse builds the animation of a JSON document like Load
+func parse(t *testing.T, doc string) (*Animation, error) {
+	var f animationFile
+	if err := json.Unmarshal([]byte(doc), &f); err != nil {
+		t.Fatal(err)
+	}
+	return f.build()
+}
+
+func testWorld() *pm.World {
+	gray := pm.NewDiffuse(ray.NewColor(0.5, 0.5, 0.5))
+	return &pm.World{pm.NewSphere(0, 0, 0, 1, gray), pm.NewSphere(3, 0, 0, 0.5, gray)}
+}
+
+const moving = `{"objects": [{"index": 1, "keys": [
+	{"frame": 0, "translate": [0, 0, 0], "scale": 1},
+	{"frame": 10, "translate": [0, 5, 0], "scale": 3}]}]}`
+
+func TestBindChecksIndex(t *testing.T) {
+	for _, index := range []string{"-1", "2", "100"} {
+		a, err := parse(t, `{"objects": [{"index": `+index+`, "keys": [{"frame": 0, "scale": 2}]}]}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := a.Bind(testWorld()); err == nil {
+			t.Errorf("index %s: expect an error for a world of 2 objects", index)
+		}
+	}
+	a, err := parse(t, moving)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Bind(testWorld()); err != nil {
+		t.Errorf("index 1: %v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	a, err := parse(t, moving)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := testWorld()
+	b, err := a.Bind(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := (*w)[1].(*pm.Sphere)
+	check := func(what string, center vec3.Vec3, radius float64) {
+		t.Helper()
+		if *s.Center != center || s.Radius != radius {
+			t.Errorf("%s: center %v radius %g, want %v and %g", what, *s.Center, s.Radius, center, radius)
+		}
+	}
+
+	b.Apply(4)
+	check("frame 4", vec3.Vec3{X: 3, Y: 2}, 0.9)
+	// frames are relative to the loaded scene, not to the last frame
+	b.Apply(4)
+	check("frame 4 applied twice", vec3.Vec3{X: 3, Y: 2}, 0.9)
+	b.Apply(10)
+	b.Apply(4)
+	check("frame 4 after frame 10", vec3.Vec3{X: 3, Y: 2}, 0.9)
+	b.Apply(0)
+	check("frame 0", vec3.Vec3{X: 3}, 0.5)
+	if c := (*w)[0].(*pm.Sphere); *c.Center != (vec3.Vec3{}) || c.Radius != 1 {
+		t.Errorf("unanimated object moved to %v radius %g", *c.Center, c.Radius)
+	}
+}
+
+func TestCamera(t *testing.T) {
+	a, err := parse(t, `{"camera": [{"frame": 0, "fov": 20}, {"frame": 10, "fov": 40}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Start != 0 || a.End != 10 {
+		t.Errorf("frames %d-%d, expect the keys' 0-10", a.Start, a.End)
+	}
+	pos, lookAt := vec3.Vec3{X: 1, Y: 2, Z: 3}, vec3.Vec3{Z: -1}
+	gotPos, gotLookAt, fov := a.Camera(5, pos, lookAt, 90)
+	if gotPos != pos || gotLookAt != lookAt {
+		t.Errorf("unanimated pos and lookAt changed to %v and %v", gotPos, gotLookAt)
+	}
+	if fov != 30 {
+		t.Errorf("fov %g at frame 5, want 30", fov)
+	}
+}
+
+func TestBuildRejects(t *testing.T) {
+	cases := map[string]string{
+		"unsorted keys": `{"camera": [{"frame": 5, "fov": 20}, {"frame": 0, "fov": 40}]}`,
+		"object keyed twice": `{"objects": [{"index": 0, "keys": [{"frame": 0, "scale": 2}]},
+			{"index": 0, "keys": [{"frame": 0, "scale": 3}]}]}`,
+		"end before start": `{"start": 5, "end": 2, "camera": [{"frame": 0, "fov": 20}]}`,
+		"interpolation":    `{"interpolation": "cubic"}`,
+	}
+	for name, doc := range cases {
+		if _, err := parse(t, doc); err == nil {
+			t.Errorf("%s: expect an error", name)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package anim
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Interpolation tells how a track goes from one key to the next
+type Interpolation int
+
+const (
+	// Linear interpolates straight between keys
+	Linear Interpolation = iota
+	// Spline follows a Catmull-Rom spline through the keys, smooth at
+	// every key
+	Spline
+)
+
+// ParseInterpolation returns the interpolation called name, an empty
+// name is linear
+func ParseInterpolation(name string) (Interpolation, error) {
+	switch name {
+	case "", "linear":
+		return Linear, nil
+	case "spline":
+		return Spline, nil
+	}
+	return Linear, fmt.Errorf("unknown interpolation %q, expect linear or spline", name)
+}
+
+// Key is the value of a track at one frame
+type Key struct {
+	Frame float64
+	Value []float64
+}
+
+// Track interpolates values of a fixed size between keys, before the
+// first key and after the last one it holds their value
+type Track struct {
+	keys   []Key
+	interp Interpolation
+}
+
+// NewTrack checks keys all have the same size and come in increasing
+// frame order, a key out of order is more likely a typo than intended
+func NewTrack(keys []Key, interp Interpolation) (*Track, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("track has no key")
+	}
+	for i, k := range keys {
+		if len(k.Value) != len(keys[0].Value) {
+			return nil, fmt.Errorf("key at frame %g has %d values, expect %d", k.Frame, len(k.Value), len(keys[0].Value))
+		}
+		switch {
+		case i == 0:
+		case k.Frame == keys[i-1].Frame:
+			return nil, fmt.Errorf("two keys at frame %g", k.Frame)
+		case k.Frame < keys[i-1].Frame:
+			return nil, fmt.Errorf("key at frame %g comes after frame %g, keys must be in increasing frame order", k.Frame, keys[i-1].Frame)
+		}
+	}
+	return &Track{keys: append([]Key(nil), keys...), interp: interp}, nil
+}
+
+// Range returns the frames of the first and last keys
+func (t *Track) Range() (first, last float64) {
+	return t.keys[0].Frame, t.keys[len(t.keys)-1].Frame
+}
+
+// At returns the value of the track at frame
+func (t *Track) At(frame float64) []float64 {
+	n := len(t.keys)
+	// i is the first key after frame
+	i := sort.Search(n, func(i int) bool { return t.keys[i].Frame > frame })
+	switch {
+	case i == 0:
+		return append([]float64(nil), t.keys[0].Value...)
+	case i == n:
+		return append([]float64(nil), t.keys[n-1].Value...)
+	}
+
+	k1, k2 := t.keys[i-1], t.keys[i]
+	s := (frame - k1.Frame) / (k2.Frame - k1.Frame)
+	out := make([]float64, len(k1.Value))
+	if t.interp == Linear {
+		for c := range out {
+			out[c] = k1.Value[c] + s*(k2.Value[c]-k1.Value[c])
+		}
+		return out
+	}
+
+	// cubic Hermite with Catmull-Rom tangents, scaled to the length of
+	// the segment since keys need not be evenly spaced
+	span := k2.Frame - k1.Frame
+	h00 := 2*s*s*s - 3*s*s + 1
+	h10 := s*s*s - 2*s*s + s
+	h01 := -2*s*s*s + 3*s*s
+	h11 := s*s*s - s*s
+	m1, m2 := t.tangent(i-1), t.tangent(i)
+	for c := range out {
+		out[c] = h00*k1.Value[c] + h10*span*m1[c] + h01*k2.Value[c] + h11*span*m2[c]
+	}
+	return out
+}
+
+// tangent returns the derivative per frame at key i, from its neighbours,
+// one sided at both ends
+func (t *Track) tangent(i int) []float64 {
+	lo, hi := i-1, i+1
+	if lo < 0 {
+		lo = 0
+	}
+	if hi >= len(t.keys) {
+		hi = len(t.keys) - 1
+	}
+	a, b := t.keys[lo], t.keys[hi]
+	m := make([]float64, len(a.Value))
+	for c := range m {
+		m[c] = (b.Value[c] - a.Value[c]) / (b.Frame - a.Frame)
+	}
+	return m
+}
@@ -0,0 +1,89 @@
+package anim
+
+import (
+	"math"
+	"testing"
+)
+
+func keys(frameValues ...float64) []Key {
+	var ks []Key
+	for i := 0; i+1 < len(frameValues); i += 2 {
+		ks = append(ks, Key{frameValues[i], []float64{frameValues[i+1]}})
+	}
+	return ks
+}
+
+func TestTrackAt(t *testing.T) {
+	cases := []struct {
+		name   string
+		keys   []Key
+		interp Interpolation
+		frame  float64
+		want   float64
+	}{
+		{"linear before first", keys(2, 10, 4, 20), Linear, 0, 10},
+		{"linear on key", keys(2, 10, 4, 20), Linear, 4, 20},
+		{"linear between", keys(2, 10, 4, 20), Linear, 2.5, 12.5},
+		{"linear after last", keys(2, 10, 4, 20), Linear, 9, 20},
+		{"linear uneven", keys(0, 0, 1, 10, 5, 50), Linear, 3, 30},
+		{"linear single key", keys(3, 7), Linear, 8, 7},
+		{"spline before first", keys(0, 0, 1, 1, 2, 0), Spline, -3, 0},
+		{"spline on key", keys(0, 0, 1, 1, 2, 0), Spline, 1, 1},
+		{"spline after last", keys(0, 0, 1, 1, 2, 0), Spline, 5, 0},
+		// tangents 1 at the first key, one sided, and 0 at the peak
+		{"spline between", keys(0, 0, 1, 1, 2, 0), Spline, 0.5, 0.625},
+		// tangents are per frame, so uneven keys on a line stay on it
+		{"spline uneven line", keys(0, 0, 1, 1, 3, 3), Spline, 2, 2},
+		{"spline uneven line start", keys(0, 0, 1, 1, 3, 3), Spline, 0.25, 0.25},
+		// one third into a segment of 3 frames, tangents 0 and -1/3
+		{"spline uneven", keys(0, 0, 1, 1, 4, 0), Spline, 2, 22.0 / 27},
+	}
+	for _, c := range cases {
+		tr, err := NewTrack(c.keys, c.interp)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if got := tr.At(c.frame)[0]; math.Abs(got-c.want) > 1e-12 {
+			t.Errorf("%s: value %g at frame %g, want %g", c.name, got, c.frame, c.want)
+		}
+	}
+}
+
+func TestTrackAtCopies(t *testing.T) {
+	tr, err := NewTrack(keys(0, 1), Linear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.At(0)[0] = 5
+	if v := tr.At(0)[0]; v != 1 {
+		t.Errorf("changing a returned value changed the key to %g", v)
+	}
+}
+
+func TestNewTrackRejects(t *testing.T) {
+	cases := []struct {
+		name string
+		keys []Key
+	}{
+		{"no key", nil},
+		{"duplicate frame", keys(0, 1, 2, 3, 2, 4)},
+		{"unsorted frames", keys(0, 1, 3, 3, 2, 4)},
+		{"sizes differ", []Key{{0, []float64{1}}, {1, []float64{1, 2}}}},
+	}
+	for _, c := range cases {
+		if _, err := NewTrack(c.keys, Linear); err == nil {
+			t.Errorf("%s: expect an error", c.name)
+		}
+	}
+}
+
+func TestParseInterpolation(t *testing.T) {
+	for name, want := range map[string]Interpolation{"": Linear, "linear": Linear, "spline": Spline} {
+		if got, err := ParseInterpolation(name); err != nil || got != want {
+			t.Errorf("%q: %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseInterpolation("cubic"); err == nil {
+		t.Error("expect an error for an unknown interpolation")
+	}
+}
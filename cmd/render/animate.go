@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime/trace"
+	"strings"
+
+	"github.com/Oaklight/Rayerson/anim"
+	pm "github.com/Oaklight/Rayerson/primitives"
+	"github.com/Oaklight/Rayerson/render"
+)
+
+func runAnimate(name string, args []string) (err error) {
+	opts := render.DefaultOptions()
+	fs := opts.FlagSet(name, os.Stderr)
+	animPath := fs.String("anim", "", "animation file keying the camera and objects (required)")
+	start := fs.Int("start", -1, "first frame to render, -1 starts where the animation does")
+	end := fs.Int("end", -1, "last frame to render, -1 ends where the animation does")
+	overwrite := fs.Bool("overwrite", false, "render frames whose image already exists")
+	moviePath := fs.String("movie", "", "also encode the frames into this animated .gif, .png or .apng")
+	movieOptions := movieFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] -anim <animation file> <scene file> <output pattern>\n\n", name)
+		fmt.Fprintln(fs.Output(), "Renders the frames of an animation into numbered images, the output pattern")
+		fmt.Fprintln(fs.Output(), "holds a printf verb like out-%04d.png, or gets -0000 before its extension.")
+		fmt.Fprintln(fs.Output(), "Frames already written are skipped. Accepts all render flags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 || *animPath == "" {
+		fs.Usage()
+		return errUsage
+	}
+	opts.Scene, opts.Output = fs.Arg(0), fs.Arg(1)
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.Progressive || opts.Checkpoint != "" || opts.Heatmap != "" || opts.AOVs != "" {
+		return fmt.Errorf("animate does not support -progressive, -checkpoint, -heatmap or -aovs")
+	}
+	mo, err := movieOptions()
+	if err != nil {
+		return err
+	}
+	if *moviePath != "" && opts.Views > 1 && opts.Layout == "separate" {
+		return fmt.Errorf("-movie needs the side-by-side layout of views")
+	}
+
+	prof, err := startProfiles(opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if perr := prof.stop(); err == nil {
+			err = perr
+		}
+	}()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, task := trace.NewTask(ctx, "animate")
+	defer task.End()
+
+	a, err := anim.Load(*animPath)
+	if err != nil {
+		return err
+	}
+	if *start >= 0 {
+		a.Start = *start
+	}
+	if *end >= 0 {
+		a.End = *end
+	}
+
+	// the world is loaded once, every frame moves its objects in place
+	var w *pm.World
+	err = phase(ctx, "parse", func(context.Context) (err error) {
+		w, err = render.LoadScene(opts.Scene)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	binding, err := a.Bind(w)
+	if err != nil {
+		return fmt.Errorf("%s: %v", *animPath, err)
+	}
+
+	var frames []string
+	for frame := a.Start; frame <= a.End; frame++ {
+		out := framePath(opts.Output, frame)
+		frames = append(frames, out)
+		if !*overwrite && frameExists(opts, out) {
+			fmt.Fprintf(os.Stderr, "frame %d: %s exists, skipped\n", frame, out)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "frame %d of %d-%d\n", frame, a.Start, a.End)
+
+		f := *opts
+		f.Output = out
+		f.Pos, f.LookAt, f.FOV = a.Camera(float64(frame), opts.Pos, opts.LookAt, opts.FOV)
+		binding.Apply(float64(frame))
+		// spline keys may overshoot into a camera the flags would refuse
+		if err := f.Validate(); err != nil {
+			return fmt.Errorf("frame %d: %v", frame, err)
+		}
+		if f.Autofocus != "" {
+			if err := phase(ctx, "build", func(context.Context) error { return f.FocusOn(w) }); err != nil {
+				return fmt.Errorf("frame %d: %v", frame, err)
+			}
+		}
+		if err := renderViews(ctx, &f, w); err != nil {
+			return fmt.Errorf("frame %d: %v", frame, err)
+		}
+	}
+
+	if *moviePath != "" {
+		return phase(ctx, "encode", func(context.Context) error { return saveMovie(*moviePath, frames, mo) })
+	}
+	return nil
+}
+
+// framePath returns the output of frame, pattern either holds a printf
+// verb or gets the zero padded frame number before its extension
+func framePath(pattern string, frame int) string {
+	if strings.Contains(pattern, "%") {
+		return fmt.Sprintf(pattern, frame)
+	}
+	ext := filepath.Ext(pattern)
+	return fmt.Sprintf("%s-%04d%s", strings.TrimSuffix(pattern, ext), frame, ext)
+}
+
+// frameExists reports whether every image of the frame written to out has
+// been written already
+func frameExists(opts *render.Options, out string) bool {
+	paths := []string{out}
+	if opts.Views > 1 && opts.Layout == "separate" {
+		paths = paths[:0]
+		for i := 0; i < opts.Views; i++ {
+			paths = append(paths, render.ViewPath(out, i, opts.Views))
+		}
+	}
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			return false
+		}
+	}
+	return true
+}
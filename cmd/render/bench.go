@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Oaklight/Rayerson/render"
+)
+
+// benchResult is the timing of one scene at one size and thread count.
+// Speedup and efficiency compare it with the fewest threads benchmarked
+// for the same scene and size.
+type benchResult struct {
+	Scene      string  `json:"scene"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Samples    int     `json:"spp"`
+	Threads    int     `json:"threads"`
+	BestMs     float64 `json:"bestMs"`
+	MeanMs     float64 `json:"meanMs"`
+	Mrays      float64 `json:"mraysPerSec"`
+	Speedup    float64 `json:"speedup"`
+	Efficiency float64 `json:"efficiency"`
+}
+
+// benchReport is written as JSON, the date and machine make reports of
+// several runs comparable
+type benchReport struct {
+	Date    time.Time     `json:"date"`
+	Go      string        `json:"go"`
+	OS      string        `json:"os"`
+	Arch    string        `json:"arch"`
+	CPUs    int           `json:"cpus"`
+	Repeat  int           `json:"repeat"`
+	Results []benchResult `json:"results"`
+}
+
+// parseInts splits a comma separated list of positive integers
+func parseInts(s string) ([]int, error) {
+	var values []int
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || v < 1 {
+			return nil, fmt.Errorf("expect positive integers separated by commas, got %q", part)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// parseSizes splits a comma separated list of sizes like 200x100
+func parseSizes(s string) ([][2]int, error) {
+	var sizes [][2]int
+	for _, part := range strings.Split(s, ",") {
+		var w, h int
+		if n, err := fmt.Sscanf(strings.TrimSpace(part), "%dx%d", &w, &h); n != 2 || err != nil || w < 1 || h < 1 {
+			return nil, fmt.Errorf("expect sizes like 200x100 separated by commas, got %q", part)
+		}
+		sizes = append(sizes, [2]int{w, h})
+	}
+	return sizes, nil
+}
+
+// defaultThreadCounts doubles from 1 up to every CPU
+func defaultThreadCounts() string {
+	var counts []string
+	for n := 1; n < runtime.NumCPU(); n *= 2 {
+		counts = append(counts, strconv.Itoa(n))
+	}
+	return strings.Join(append(counts, strconv.Itoa(runtime.NumCPU())), ",")
+}
+
+func runBench(name string, args []string) (err error) {
+	opts := render.DefaultOptions()
+	opts.Width, opts.Height, opts.Samples = 200, 100, 16
+	opts.NoiseThreshold = 0
+	fs := opts.FlagSet(name, os.Stderr)
+	repeat := fs.Int("repeat", 3, "renders per scene, the fastest one is reported")
+	threadList := fs.String("thread-counts", "", "comma separated thread counts to time, e.g. 1,2,4,8,\n"+
+		"-threads alone when empty, -threads 0 doubles up to every CPU: "+defaultThreadCounts())
+	sizeList := fs.String("sizes", "", "comma separated image sizes like 200x100,800x400, -width and -height when empty")
+	format := fs.String("format", "text", "report format: text, csv or json")
+	output := fs.String("o", "", "write the report to this file rather than stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] <scene file>...\n\n", name)
+		fmt.Fprintln(fs.Output(), "Times renders of every scene at every size and thread count, with the speedup")
+		fmt.Fprintln(fs.Output(), "and efficiency over the fewest threads. Accepts all render flags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 || *repeat < 1 {
+		fs.Usage()
+		return errUsage
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	switch *format {
+	case "text", "csv", "json":
+	default:
+		return fmt.Errorf("-format must be text, csv or json, got %q", *format)
+	}
+
+	counts := []int{opts.Threads}
+	switch {
+	case *threadList != "":
+		var err error
+		if counts, err = parseInts(*threadList); err != nil {
+			return fmt.Errorf("-thread-counts: %v", err)
+		}
+	case opts.Threads == 0:
+		counts, _ = parseInts(defaultThreadCounts())
+	}
+	sizes := [][2]int{{opts.Width, opts.Height}}
+	if *sizeList != "" {
+		var err error
+		if sizes, err = parseSizes(*sizeList); err != nil {
+			return fmt.Errorf("-sizes: %v", err)
+		}
+	}
+
+	prof, err := startProfiles(opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if perr := prof.stop(); err == nil {
+			err = perr
+		}
+	}()
+
+	report := benchReport{
+		Date:   time.Now().UTC().Truncate(time.Second),
+		Go:     runtime.Version(),
+		OS:     runtime.GOOS,
+		Arch:   runtime.GOARCH,
+		CPUs:   runtime.NumCPU(),
+		Repeat: *repeat,
+	}
+	for _, path := range fs.Args() {
+		w, err := render.LoadScene(path)
+		if err != nil {
+			return err
+		}
+		for _, size := range sizes {
+			o := *opts
+			o.Width, o.Height = size[0], size[1]
+			if err := o.FocusOn(w); err != nil {
+				return err
+			}
+			first, base := len(report.Results), -1
+			for _, threads := range counts {
+				o.Threads = threads
+				fmt.Fprintf(os.Stderr, "%s %dx%d, %d threads\n", path, o.Width, o.Height, threads)
+				var best, total time.Duration
+				var stats render.RayStats
+				for i := 0; i < *repeat; i++ {
+					sampler, err := o.NewSampler()
+					if err != nil {
+						return err
+					}
+					sampler.SetWorldObj(w)
+					start := time.Now()
+					err = phase(context.Background(), "render", func(ctx context.Context) error {
+						_, err := sampler.Render(ctx)
+						return err
+					})
+					if err != nil {
+						return err
+					}
+					elapsed := time.Since(start)
+					total += elapsed
+					if i == 0 || elapsed < best {
+						best, stats = elapsed, sampler.RayStats()
+					}
+				}
+
+				r := benchResult{
+					Scene:   path,
+					Width:   o.Width,
+					Height:  o.Height,
+					Samples: o.Samples,
+					Threads: threads,
+					BestMs:  float64(best) / float64(time.Millisecond),
+					MeanMs:  float64(total) / float64(*repeat) / float64(time.Millisecond),
+					Mrays:   stats.MraysPerSec(),
+				}
+				if base < 0 || threads < report.Results[base].Threads {
+					base = len(report.Results)
+				}
+				report.Results = append(report.Results, r)
+			}
+			// the fewest threads may not come first
+			b := report.Results[base]
+			for i := first; i < len(report.Results); i++ {
+				r := &report.Results[i]
+				r.Speedup = b.BestMs / r.BestMs
+				r.Efficiency = r.Speedup * float64(b.Threads) / float64(r.Threads)
+			}
+		}
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		outWriter, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer outWriter.Close()
+		out = outWriter
+	}
+	switch *format {
+	case "csv":
+		return writeBenchCSV(out, report)
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return writeBenchText(out, report)
+}
+
+func writeBenchText(w io.Writer, report benchReport) error {
+	fmt.Fprintf(w, "%d spp, best of %d, %d CPUs, %s\n", report.Results[0].Samples, report.Repeat, report.CPUs, report.Go)
+	for _, r := range report.Results {
+		size := fmt.Sprintf("%dx%d", r.Width, r.Height)
+		_, err := fmt.Fprintf(w, "%-30s %-9s %3d threads  best %9.1f ms  mean %9.1f ms  %6.2f Mrays/s  %5.2fx  %3.0f%%\n",
+			r.Scene, size, r.Threads, r.BestMs, r.MeanMs, r.Mrays, r.Speedup, 100*r.Efficiency)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBenchCSV writes one row per result, repeating the date so that the
+// rows of several runs can be appended into one file
+func writeBenchCSV(w io.Writer, report benchReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "cpus", "scene", "width", "height", "spp", "threads",
+		"best_ms", "mean_ms", "mrays_per_s", "speedup", "efficiency"})
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	for _, r := range report.Results {
+		cw.Write([]string{report.Date.Format(time.RFC3339), strconv.Itoa(report.CPUs), r.Scene,
+			strconv.Itoa(r.Width), strconv.Itoa(r.Height), strconv.Itoa(r.Samples), strconv.Itoa(r.Threads),
+			f(r.BestMs), f(r.MeanMs), f(r.Mrays), f(r.Speedup), f(r.Efficiency)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Oaklight/Rayerson/render"
+)
+
+func runConvert(name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	format := fs.String("format", "",
+		"output format: "+strings.Join(render.SceneFormatNames(), ", ")+", default from the output extension")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] <scene file> <output file>\n\n", name)
+		fmt.Fprintln(fs.Output(), "Rewrites a scene in another format, or normalizes it when both files have")
+		fmt.Fprintln(fs.Output(), "the same format. The output file - writes to stdout. Flags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+	in, out := fs.Arg(0), fs.Arg(1)
+
+	w, err := render.LoadScene(in)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *format == "" && out != "-":
+		return render.SaveScene(out, w)
+	case *format == "":
+		return fmt.Errorf("writing to stdout needs -format")
+	}
+	f, err := render.SceneFormatByName(*format)
+	if err != nil {
+		return err
+	}
+	if out == "-" {
+		buffered := bufio.NewWriter(os.Stdout)
+		if err := f.Write(buffered, w); err != nil {
+			return err
+		}
+		return buffered.Flush()
+	}
+
+	return f.Save(out, w)
+}
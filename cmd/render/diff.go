@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/Oaklight/Rayerson/imgcmp"
+)
+
+func runDiff(name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	output := fs.String("o", "", "write a difference image to this file")
+	gain := fs.Float64("gain", 10, "amplification of the difference image")
+	heatmap := fs.String("heatmap", "", "write the perceptual FLIP error map to this file")
+	ppd := fs.Float64("ppd", imgcmp.DefaultPPD, "pixels per degree the images are seen at, for -heatmap")
+	maxMSE := fs.Float64("max-mse", -1, "fail when the MSE exceeds this, negative never fails")
+	minSSIM := fs.Float64("min-ssim", -1, "fail when the SSIM is below this, negative never fails")
+	maxFLIP := fs.Float64("max-flip", -1, "fail when the mean FLIP error exceeds this, negative never fails")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] <image> <image>\n\nCompares two images. Flags:\n", name)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+
+	a, err := imgcmp.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	b, err := imgcmp.Load(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	report, err := imgcmp.Compare(a, b)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report)
+
+	if *output != "" {
+		diff, err := imgcmp.DiffImage(a, b, *gain)
+		if err != nil {
+			return err
+		}
+		if err := savePNG(*output, diff); err != nil {
+			return err
+		}
+	}
+	if *heatmap != "" {
+		flip, err := imgcmp.FLIP(a, b, *ppd)
+		if err != nil {
+			return err
+		}
+		if err := savePNG(*heatmap, flip.Heatmap()); err != nil {
+			return err
+		}
+	}
+	switch {
+	case *maxMSE >= 0 && report.MSE > *maxMSE:
+		return fmt.Errorf("MSE %.6g exceeds %.6g", report.MSE, *maxMSE)
+	case *minSSIM >= 0 && report.SSIM < *minSSIM:
+		return fmt.Errorf("SSIM %.4f is below %.4f", report.SSIM, *minSSIM)
+	case *maxFLIP >= 0 && report.FLIP > *maxFLIP:
+		return fmt.Errorf("FLIP %.4f exceeds %.4f", report.FLIP, *maxFLIP)
+	}
+	return nil
+}
+
+func savePNG(filePath string, img image.Image) error {
+	outWriter, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(outWriter, img); err != nil {
+		outWriter.Close()
+		return err
+	}
+	return outWriter.Close()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Oaklight/Rayerson/render"
+)
+
+func runGenerate(name string, args []string) error {
+	opts := render.DefaultGeneratorOptions()
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Int64Var(&opts.Seed, "seed", opts.Seed, "seed of the random placement and materials")
+	fs.IntVar(&opts.Grid, "grid", opts.Grid, "small spheres are placed on a 2*grid by 2*grid lattice")
+	fs.Float64Var(&opts.Jitter, "jitter", opts.Jitter, "how far a sphere may move within its lattice cell")
+	fs.Float64Var(&opts.RadiusMin, "radius-min", opts.RadiusMin, "smallest radius of small spheres")
+	fs.Float64Var(&opts.RadiusMax, "radius-max", opts.RadiusMax, "largest radius of small spheres")
+	fs.Float64Var(&opts.Diffuse, "diffuse", opts.Diffuse, "probability of a diffuse sphere")
+	fs.Float64Var(&opts.Metallic, "metallic", opts.Metallic, "probability of a metallic sphere, the rest is glass")
+	fs.Float64Var(&opts.FuzzMin, "fuzz-min", opts.FuzzMin, "smallest fuzziness of metals")
+	fs.Float64Var(&opts.FuzzMax, "fuzz-max", opts.FuzzMax, "largest fuzziness of metals")
+	fs.Float64Var(&opts.RefIdx, "ref-idx", opts.RefIdx, "refractive index of glass")
+	fs.BoolVar(&opts.Floor, "floor", opts.Floor, "add the ground sphere")
+	fs.BoolVar(&opts.AvoidOverlap, "avoid-overlap", opts.AvoidOverlap, "drop spheres intersecting placed ones")
+	heroes := fs.Bool("heroes", true, "add the three large spheres")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] <scene file>\n\n", name)
+		fmt.Fprintln(fs.Output(), "Generates a random scene, the format follows the file extension. Flags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	if !*heroes {
+		opts.Heroes = nil
+	}
+	switch {
+	case opts.Grid < 0:
+		return fmt.Errorf("-grid must not be negative, got %d", opts.Grid)
+	case opts.RadiusMin <= 0 || opts.RadiusMax < opts.RadiusMin:
+		return fmt.Errorf("-radius-min must be positive and at most -radius-max")
+	case opts.Diffuse < 0 || opts.Metallic < 0 || opts.Diffuse+opts.Metallic > 1:
+		return fmt.Errorf("-diffuse and -metallic must be probabilities summing to at most 1")
+	case opts.FuzzMin < 0 || opts.FuzzMax < opts.FuzzMin:
+		return fmt.Errorf("-fuzz-min must not be negative and at most -fuzz-max")
+	}
+
+	w := render.GenerateScene(opts)
+	if err := render.SaveScene(fs.Arg(0), w); err != nil {
+		return err
+	}
+	fmt.Fprint(os.Stdout, render.DescribeScene(w))
+	return nil
+}
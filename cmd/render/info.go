@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/Oaklight/Rayerson/render"
+)
+
+func runInfo(name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s <scene file>...\n\nPrints object and material counts and the bounds of scenes.\n", name)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	for i, path := range fs.Args() {
+		if i > 0 {
+			fmt.Println()
+		}
+		w, err := render.LoadScene(path)
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		fmt.Print(render.DescribeScene(w))
+	}
+	return nil
+}
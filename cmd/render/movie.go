@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Oaklight/Rayerson/movie"
+)
+
+// movieFlags binds the encoding flags of animations onto fs, the returned
+// function gives the options once fs is parsed
+func movieFlags(fs *flag.FlagSet) func() (movie.Options, error) {
+	o := movie.DefaultOptions()
+	fs.Float64Var(&o.FPS, "fps", o.FPS, "frames per second of the animation")
+	fs.IntVar(&o.Loops, "loops", o.Loops, "times the animation plays, 0 loops forever")
+	fs.IntVar(&o.Colors, "colors", o.Colors, "colors of the GIF palette, 2 to 256")
+	dither := fs.String("dither", movie.DitherNames[o.Dither],
+		"GIF dithering: "+strings.Join(movie.DitherNames, ", "))
+	return func() (movie.Options, error) {
+		var err error
+		if o.Dither, err = movie.ParseDither(*dither); err != nil {
+			return o, fmt.Errorf("-dither: %v", err)
+		}
+		if o.FPS <= 0 {
+			return o, fmt.Errorf("-fps must be positive, got %g", o.FPS)
+		}
+		if o.Loops < 0 {
+			return o, fmt.Errorf("-loops must not be negative, got %d", o.Loops)
+		}
+		return o, nil
+	}
+}
+
+// saveMovie encodes the images at paths into one animation
+func saveMovie(filePath string, paths []string, o movie.Options) error {
+	frames, err := movie.LoadFrames(paths)
+	if err != nil {
+		return err
+	}
+	return movie.Save(filePath, frames, o)
+}
+
+func runMovie(name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	options := movieFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] <output file> <frame image>...\n\n", name)
+		fmt.Fprintln(fs.Output(), "Encodes frames into an animated GIF (.gif) or PNG (.png, .apng). Flags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errUsage
+	}
+	o, err := options()
+	if err != nil {
+		return err
+	}
+	if err := saveMovie(fs.Arg(0), fs.Args()[1:], o); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d frames\n", fs.Arg(0), fs.NArg()-1)
+	return nil
+}
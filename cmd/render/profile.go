@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"github.com/Oaklight/Rayerson/render"
+)
+
+// profiler writes the profiles asked by -cpuprofile, -memprofile,
+// -allocprofile and -trace
+type profiler struct {
+	opts       *render.Options
+	cpu, trace *os.File
+}
+
+// startProfiles starts the CPU profile and the execution trace, stop
+// writes them and the memory profiles
+func startProfiles(opts *render.Options) (*profiler, error) {
+	p := &profiler{opts: opts}
+	var err error
+	if opts.CPUProfile != "" {
+		if p.cpu, err = os.Create(opts.CPUProfile); err != nil {
+			return nil, err
+		}
+		if err := pprof.StartCPUProfile(p.cpu); err != nil {
+			p.cpu.Close()
+			return nil, fmt.Errorf("%s: %v", opts.CPUProfile, err)
+		}
+	}
+	if opts.Trace != "" {
+		if p.trace, err = os.Create(opts.Trace); err == nil {
+			if err = trace.Start(p.trace); err != nil {
+				p.trace.Close()
+				p.trace, err = nil, fmt.Errorf("%s: %v", opts.Trace, err)
+			}
+		}
+		if err != nil {
+			p.stop()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// stop ends the profiles and reports the first error writing them
+func (p *profiler) stop() error {
+	var first error
+	keep := func(err error) {
+		if first == nil {
+			first = err
+		}
+	}
+	if p.cpu != nil {
+		pprof.StopCPUProfile()
+		keep(p.cpu.Close())
+		p.cpu = nil
+	}
+	if p.trace != nil {
+		trace.Stop()
+		keep(p.trace.Close())
+		p.trace = nil
+	}
+	// the heap profile shows the memory in use at the last collection
+	runtime.GC()
+	for _, prof := range []struct{ name, path string }{
+		{"heap", p.opts.MemProfile},
+		{"allocs", p.opts.AllocProfile},
+	} {
+		if prof.path != "" {
+			keep(writeProfile(prof.name, prof.path))
+		}
+	}
+	return first
+}
+
+func writeProfile(name, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pprof.Lookup(name).WriteTo(f, 0); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return f.Close()
+}
+
+// phase runs f labelled phase=name in the CPU profile and as a region of
+// the execution trace. The render workers started by f inherit the label,
+// so pprof -tagfocus phase=render keeps their samples.
+//
+// The phases are parse for loading the scene, build for setting up the
+// world and the sampler (the world has no acceleration structure, its
+// objects are all tested for each ray), render, denoise and encode for
+// writing the images.
+func phase(ctx context.Context, name string, f func(ctx context.Context) error) error {
+	var err error
+	pprof.Do(ctx, pprof.Labels("phase", name), func(ctx context.Context) {
+		trace.WithRegion(ctx, name, func() { err = f(ctx) })
+	})
+	return err
+}
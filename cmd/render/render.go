@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime/trace"
+	"strings"
+
+	pm "github.com/Oaklight/Rayerson/primitives"
+	"github.com/Oaklight/Rayerson/render"
+)
+
+// command is one subcommand of the render binary
+type command struct {
+	name, summary string
+	run           func(name string, args []string) error
+}
+
+var commands = []command{
+	{"render", "render a scene into an image (the default)", runRender},
+	{"animate", "render the numbered frames of a keyframed animation", runAnimate},
+	{"movie", "encode frames into an animated GIF or PNG", runMovie},
+	{"generate", "generate a random scene file", runGenerate},
+	{"convert", "convert or normalize scene files", runConvert},
+	{"info", "print object and material counts and bounds of scenes", runInfo},
+	{"bench", "time standard renders of scenes", runBench},
+	{"diff", "compare two images", runDiff},
+}
+
+// errUsage reports bad arguments, usage has already been printed
+var errUsage = errors.New("invalid arguments")
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [command] [flags] [arguments]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun %s <command> -h for the flags of a command.\n", os.Args[0])
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	if arg := os.Args[1]; arg == "help" || arg == "-h" || arg == "-help" || arg == "--help" {
+		usage()
+		return
+	}
+
+	// without a known command the arguments are those of render
+	run, name, args := runRender, os.Args[0], os.Args[1:]
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			run, name, args = c.run, os.Args[0]+" "+c.name, os.Args[2:]
+		}
+	}
+
+	switch err := run(name, args); {
+	case err == nil:
+	case err == flag.ErrHelp:
+	case err == errUsage:
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runRender(name string, args []string) (err error) {
+	opts, err := render.ParseArgs(name, args, os.Stderr)
+	if err != nil {
+		return err
+	}
+	prof, err := startProfiles(opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if perr := prof.stop(); err == nil {
+			err = perr
+		}
+	}()
+
+	// an interrupt cancels the render, keeping what is done so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, task := trace.NewTask(ctx, "render")
+	defer task.End()
+
+	var w *pm.World
+	err = phase(ctx, "parse", func(context.Context) (err error) {
+		w, err = render.LoadScene(opts.Scene)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.Autofocus != "" {
+		err := phase(ctx, "build", func(context.Context) error { return opts.FocusOn(w) })
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "autofocus: focus distance %.4g\n", opts.FocusDist)
+	}
+
+	if opts.Views > 1 {
+		return renderViews(ctx, opts, w)
+	}
+
+	var sampler *render.Sampler
+	err = phase(ctx, "build", func(context.Context) (err error) {
+		if sampler, err = opts.NewSampler(); err != nil {
+			return err
+		}
+		sampler.SetWorldObj(w)
+		if opts.Resume {
+			if err := sampler.LoadCheckpoint(opts.Checkpoint); err != nil {
+				return err
+			}
+		}
+		sampler.SetProgress(render.NewProgressBar(os.Stderr))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// an interrupted render is still denoised and written
+	renderErr := phase(ctx, "render", func(ctx context.Context) (err error) {
+		if opts.Progressive || opts.Checkpoint != "" {
+			_, err = sampler.RenderProgressive(ctx, opts.ProgressiveOptions())
+		} else {
+			_, err = sampler.Render(ctx)
+		}
+		return err
+	})
+	fmt.Fprintln(os.Stderr)
+	fmt.Println("rays:", sampler.RayStats())
+
+	if opts.Denoise != "none" {
+		err := phase(ctx, "denoise", func(context.Context) error {
+			return sampler.Denoise(opts.DenoiseOptions())
+		})
+		if err != nil {
+			return err
+		}
+	}
+	err = phase(ctx, "encode", func(context.Context) error {
+		if err := sampler.Save(opts.Output); err != nil {
+			return err
+		}
+		if opts.Heatmap != "" {
+			if err := sampler.SaveHeatmap(opts.Heatmap); err != nil {
+				return err
+			}
+		}
+		return saveAOVs(sampler, opts)
+	})
+	if err != nil {
+		return err
+	}
+	return renderErr
+}
+
+// saveAOVs writes the buffers enabled by -aovs, each as an image or all of
+// them with the linear image in one EXR file. The EXR beauty layer is the
+// image as rendered, a denoised layer holds what the PNG shows.
+func saveAOVs(sampler *render.Sampler, opts *render.Options) error {
+	layers := sampler.AOVs()
+	if len(layers) == 0 {
+		return nil
+	}
+	if opts.AOVFormat == "exr" {
+		path := strings.TrimSuffix(opts.Output, filepath.Ext(opts.Output)) + ".exr"
+		layers = append([]*render.Layer{sampler.Beauty()}, layers...)
+		if denoised := sampler.Denoised(); denoised != nil {
+			layers = append(layers, denoised)
+		}
+		return render.SaveEXR(path, layers)
+	}
+	for _, l := range layers {
+		if err := render.SaveImage(render.AOVPath(opts.Output, l.Name), l.Image()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderViews renders every view of the camera rig from the same world,
+// then writes them side by side or each to its own file
+func renderViews(ctx context.Context, opts *render.Options, w *pm.World) error {
+	views := opts.ViewOptions()
+	imgs := make([]image.Image, len(views))
+	for i, view := range views {
+		if len(views) > 1 {
+			fmt.Fprintf(os.Stderr, "view %d of %d\n", i+1, len(views))
+		}
+		var sampler *render.Sampler
+		err := phase(ctx, "build", func(context.Context) (err error) {
+			if sampler, err = view.NewSampler(); err != nil {
+				return err
+			}
+			sampler.SetWorldObj(w)
+			sampler.SetProgress(render.NewProgressBar(os.Stderr))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		err = phase(ctx, "render", func(ctx context.Context) error {
+			_, err := sampler.Render(ctx)
+			return err
+		})
+		fmt.Fprintln(os.Stderr)
+		fmt.Println("rays:", sampler.RayStats())
+		if err != nil {
+			return err
+		}
+		if opts.Denoise != "none" {
+			err := phase(ctx, "denoise", func(context.Context) error {
+				return sampler.Denoise(opts.DenoiseOptions())
+			})
+			if err != nil {
+				return err
+			}
+		}
+		imgs[i] = sampler.ImgOut
+		if opts.Heatmap != "" {
+			err := phase(ctx, "encode", func(context.Context) error {
+				return sampler.SaveHeatmap(render.ViewPath(opts.Heatmap, i, len(views)))
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return phase(ctx, "encode", func(context.Context) error {
+		if opts.Layout == "separate" {
+			for i, img := range imgs {
+				if err := render.SaveImage(render.ViewPath(opts.Output, i, len(imgs)), img); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return render.SaveImage(opts.Output, render.SideBySide(imgs))
+	})
+}
@@ -0,0 +1,111 @@
+package exr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Channel is one plane of an uncompressed scanline OpenEXR image of 32 bit
+// floats, Data holds width*height values row by row from the top
+type Channel struct {
+	Name string
+	Data []float32
+}
+
+// exrMagic and the version with no flag set: single part scanline image
+// with short names
+const (
+	exrMagic   = 20000630
+	exrVersion = 2
+	// maxNameLength is the longest attribute or channel name without
+	// the long names flag
+	maxNameLength = 31
+	pixelFloat    = 2
+)
+
+// Encode writes the channels as an image of width x height pixels, they
+// are stored sorted by name as the format requires. Layers are named by
+// prefixing channels with the layer and a dot, like "normal.X".
+func Encode(w io.Writer, width, height int, channels []Channel) error {
+	if len(channels) == 0 {
+		return fmt.Errorf("exr: no channel")
+	}
+	sorted := append([]Channel(nil), channels...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for i, c := range sorted {
+		if len(c.Data) != width*height {
+			return fmt.Errorf("exr: channel %s has %d values, expect %d", c.Name, len(c.Data), width*height)
+		}
+		if c.Name == "" || len(c.Name) > maxNameLength {
+			return fmt.Errorf("exr: channel name %q must have 1 to %d bytes", c.Name, maxNameLength)
+		}
+		if i > 0 && c.Name == sorted[i-1].Name {
+			return fmt.Errorf("exr: two channels named %s", c.Name)
+		}
+	}
+
+	var header bytes.Buffer
+	le := func(v interface{}) {
+		binary.Write(&header, binary.LittleEndian, v)
+	}
+	attribute := func(name, kind string, value []byte) {
+		header.WriteString(name + "\x00" + kind + "\x00")
+		le(int32(len(value)))
+		header.Write(value)
+	}
+	encode := func(values ...interface{}) []byte {
+		var b bytes.Buffer
+		for _, v := range values {
+			binary.Write(&b, binary.LittleEndian, v)
+		}
+		return b.Bytes()
+	}
+
+	le(int32(exrMagic))
+	le(int32(exrVersion))
+	var chlist bytes.Buffer
+	for _, c := range sorted {
+		chlist.WriteString(c.Name + "\x00")
+		// pixel type, linear flag and reserved bytes, x and y sampling
+		chlist.Write(encode(int32(pixelFloat), uint8(0), [3]uint8{}, int32(1), int32(1)))
+	}
+	chlist.WriteByte(0)
+	attribute("channels", "chlist", chlist.Bytes())
+	attribute("compression", "compression", []byte{0})
+	window := encode(int32(0), int32(0), int32(width-1), int32(height-1))
+	attribute("dataWindow", "box2i", window)
+	attribute("displayWindow", "box2i", window)
+	attribute("lineOrder", "lineOrder", []byte{0})
+	attribute("pixelAspectRatio", "float", encode(float32(1)))
+	attribute("screenWindowCenter", "v2f", encode(float32(0), float32(0)))
+	attribute("screenWindowWidth", "float", encode(float32(1)))
+	header.WriteByte(0)
+
+	// one line per block, each block is its y, its size and the line of
+	// every channel in turn
+	lineSize := 4 * width * len(sorted)
+	blockSize := 8 + lineSize
+	offset := uint64(header.Len() + 8*height)
+
+	bw := bufio.NewWriter(w)
+	bw.Write(header.Bytes())
+	for y := 0; y < height; y++ {
+		binary.Write(bw, binary.LittleEndian, offset+uint64(y*blockSize))
+	}
+	line := make([]byte, 4*width)
+	for y := 0; y < height; y++ {
+		binary.Write(bw, binary.LittleEndian, [2]int32{int32(y), int32(lineSize)})
+		for _, c := range sorted {
+			for x, v := range c.Data[y*width : (y+1)*width] {
+				binary.LittleEndian.PutUint32(line[4*x:], math.Float32bits(v))
+			}
+			bw.Write(line)
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,3 @@
+module github.com/Oaklight/Rayerson
+
+go 1.21
@@ -0,0 +1,239 @@
+package imgcmp
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// DefaultPPD is the pixels per degree of visual angle of a 0.7 m wide 4K
+// monitor seen from 0.7 m
+const DefaultPPD = 67
+
+// ErrorMap holds a per-pixel error in [0, 1], row by row from the top
+type ErrorMap struct {
+	Width, Height int
+	Err           []float64
+}
+
+// Mean returns the error averaged over the pixels
+func (m *ErrorMap) Mean() float64 {
+	var sum float64
+	for _, e := range m.Err {
+		sum += e
+	}
+	return sum / float64(len(m.Err))
+}
+
+// magma samples the magma colormap, from black for no error to pale
+// yellow for the largest
+var magma = [...][3]float64{
+	{0, 0, 4}, {28, 16, 68}, {79, 18, 123}, {129, 37, 129}, {181, 54, 122},
+	{229, 80, 100}, {251, 135, 97}, {254, 194, 135}, {252, 253, 191},
+}
+
+// Heatmap maps the errors through the magma colormap
+func (m *ErrorMap) Heatmap() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, m.Width, m.Height))
+	for i, e := range m.Err {
+		t := math.Max(0, math.Min(1, e)) * float64(len(magma)-1)
+		j := int(math.Min(t, float64(len(magma)-2)))
+		f := t - float64(j)
+		var c [3]uint8
+		for k := range c {
+			c[k] = uint8(magma[j][k]*(1-f) + magma[j+1][k]*f + 0.5)
+		}
+		img.SetRGBA(i%m.Width, i/m.Width, color.RGBA{c[0], c[1], c[2], 255})
+	}
+	return img
+}
+
+// csfLobe is one gaussian of a contrast sensitivity function, a weighs it
+// and b is its spread in squared degrees
+type csfLobe struct{ a, b float64 }
+
+// contrast sensitivity of the achromatic, red-green and blue-yellow
+// channels, from the FLIP paper
+var csf = [3][]csfLobe{
+	{{1, 0.0047}},
+	{{1, 0.0053}},
+	{{34.1, 0.04}, {13.5, 0.025}},
+}
+
+// filterCSF blurs p like the eye does at ppd pixels per degree
+func (p *plane) filterCSF(lobes []csfLobe, ppd float64) *plane {
+	var total float64
+	for _, l := range lobes {
+		total += l.a
+	}
+	out := newPlane(p.w, p.h)
+	for _, l := range lobes {
+		k := gaussian(math.Sqrt(l.b/(2*math.Pi*math.Pi)) * ppd)
+		for i, v := range p.convolve(k, k).v {
+			out.v[i] += l.a / total * v
+		}
+	}
+	return out
+}
+
+func linearize(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// D65 white
+const whiteX, whiteY, whiteZ = 0.950428545, 1, 1.088900371
+
+// rgbToXYZ converts linear sRGB
+func rgbToXYZ(r, g, b float64) (x, y, z float64) {
+	return 0.4124564*r + 0.3575761*g + 0.1804375*b,
+		0.2126729*r + 0.7151522*g + 0.0721750*b,
+		0.0193339*r + 0.1191920*g + 0.9503041*b
+}
+
+func xyzToRGB(x, y, z float64) (r, g, b float64) {
+	return 3.2404542*x - 1.5371385*y - 0.4985314*z,
+		-0.9692660*x + 1.8760108*y + 0.0415560*z,
+		0.0556434*x - 0.2040259*y + 1.0572252*z
+}
+
+// ycxcz converts gamma encoded sRGB to the opponent space filtered by the
+// contrast sensitivity functions
+func ycxcz(r, g, b float64) []float64 {
+	x, y, z := rgbToXYZ(linearize(r), linearize(g), linearize(b))
+	x, y, z = x/whiteX, y/whiteY, z/whiteZ
+	return []float64{116*y - 16, 500 * (x - y), 200 * (y - z)}
+}
+
+// huntLab converts linear sRGB to L*a*b*, its chroma scaled by lightness
+// after the Hunt effect
+func huntLab(r, g, b float64) (l, a, bb float64) {
+	x, y, z := rgbToXYZ(r, g, b)
+	f := func(t float64) float64 {
+		const d = 6.0 / 29
+		if t > d*d*d {
+			return math.Cbrt(t)
+		}
+		return t/(3*d*d) + 4.0/29
+	}
+	fx, fy, fz := f(x/whiteX), f(y/whiteY), f(z/whiteZ)
+	l = 116*fy - 16
+	return l, 0.01 * l * 500 * (fx - fy), 0.01 * l * 200 * (fy - fz)
+}
+
+// hyab is the distance of two L*a*b* colors, lightness and chroma apart
+func hyab(l1, a1, b1, l2, a2, b2 float64) float64 {
+	return math.Abs(l1-l2) + math.Hypot(a1-a2, b1-b2)
+}
+
+// featureKernels returns the first and second derivatives of a gaussian
+// sized for ppd, each with positive weights summing to 1 and negative ones
+// to -1, and the gaussian itself
+func featureKernels(ppd float64) (edge, point, smooth []float64) {
+	sigma := 0.5 * 0.082 * ppd
+	smooth = gaussian(sigma)
+	r := len(smooth) / 2
+	edge, point = make([]float64, len(smooth)), make([]float64, len(smooth))
+	for i, g := range smooth {
+		x := float64(i - r)
+		edge[i] = -x * g
+		point[i] = (x*x/(sigma*sigma) - 1) * g
+	}
+	for _, k := range [][]float64{edge, point} {
+		var pos, neg float64
+		for _, v := range k {
+			if v > 0 {
+				pos += v
+			} else {
+				neg -= v
+			}
+		}
+		for i, v := range k {
+			if v > 0 {
+				k[i] /= pos
+			} else {
+				k[i] /= neg
+			}
+		}
+	}
+	return edge, point, smooth
+}
+
+// features returns the strength of edges and points in the luminance p
+func (p *plane) features(edge, point, smooth []float64) (edges, points *plane) {
+	ex, ey := p.convolve(edge, smooth), p.convolve(smooth, edge)
+	px, py := p.convolve(point, smooth), p.convolve(smooth, point)
+	edges, points = newPlane(p.w, p.h), newPlane(p.w, p.h)
+	for i := range p.v {
+		edges.v[i] = math.Hypot(ex.v[i], ey.v[i])
+		points.v[i] = math.Hypot(px.v[i], py.v[i])
+	}
+	return edges, points
+}
+
+// FLIP computes a perceptual error map after the FLIP metric: colors are
+// blurred as the eye sees them at ppd pixels per degree and compared in a
+// perceptual space, and the error grows where edges or points differ.
+// It follows the published method for low dynamic range images.
+func FLIP(a, b image.Image, ppd float64) (*ErrorMap, error) {
+	if err := sameSize(a, b); err != nil {
+		return nil, err
+	}
+	const (
+		// exponents of color and feature errors
+		qc, qf = 0.7, 0.5
+		// color errors below pc of the largest map to [0, pt]
+		pc, pt = 0.4, 0.95
+	)
+	// the largest color difference, between green and blue
+	l1, a1, b1 := huntLab(0, 1, 0)
+	l2, a2, b2 := huntLab(0, 0, 1)
+	cmax := math.Pow(hyab(l1, a1, b1, l2, a2, b2), qc)
+
+	filtered := func(img image.Image) [3][]float64 {
+		ps := planes(img, 3, ycxcz)
+		for i := range ps {
+			ps[i] = ps[i].filterCSF(csf[i], ppd)
+		}
+		var lab [3][]float64
+		for i := range lab {
+			lab[i] = make([]float64, len(ps[0].v))
+		}
+		clamp := func(v float64) float64 { return math.Max(0, math.Min(1, v)) }
+		for i := range ps[0].v {
+			y := (ps[0].v[i] + 16) / 116
+			x, z := ps[1].v[i]/500+y, y-ps[2].v[i]/200
+			r, g, bl := xyzToRGB(x*whiteX, y*whiteY, z*whiteZ)
+			lab[0][i], lab[1][i], lab[2][i] = huntLab(clamp(r), clamp(g), clamp(bl))
+		}
+		return lab
+	}
+	labA, labB := filtered(a), filtered(b)
+
+	luminance := func(img image.Image) *plane {
+		return planes(img, 1, func(r, g, b float64) []float64 {
+			return []float64{(ycxcz(r, g, b)[0] + 16) / 116}
+		})[0]
+	}
+	edge, point, smooth := featureKernels(ppd)
+	edgesA, pointsA := luminance(a).features(edge, point, smooth)
+	edgesB, pointsB := luminance(b).features(edge, point, smooth)
+
+	size := a.Bounds().Size()
+	m := &ErrorMap{Width: size.X, Height: size.Y, Err: make([]float64, size.X*size.Y)}
+	for i := range m.Err {
+		dc := math.Pow(hyab(labA[0][i], labA[1][i], labA[2][i], labB[0][i], labB[1][i], labB[2][i]), qc)
+		var ec float64
+		if dc < pc*cmax {
+			ec = pt / (pc * cmax) * dc
+		} else {
+			ec = math.Min(1, pt+(dc-pc*cmax)/(cmax-pc*cmax)*(1-pt))
+		}
+		df := math.Max(math.Abs(edgesA.v[i]-edgesB.v[i]), math.Abs(pointsA.v[i]-pointsB.v[i]))
+		ef := math.Pow(math.Min(1, df/math.Sqrt2), qf)
+		m.Err[i] = math.Pow(ec, 1-ef)
+	}
+	return m, nil
+}
@@ -0,0 +1,132 @@
+package imgcmp
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+)
+
+// Report holds the difference metrics between two images, channels are
+// compared as values in [0, 1]
+type Report struct {
+	// MSE is the mean squared error over all RGB channels
+	MSE float64
+	// PSNR is the peak signal to noise ratio in dB, +Inf for equal images
+	PSNR float64
+	// SSIM is the mean structural similarity, 1 for equal images
+	SSIM float64
+	// FLIP is the mean perceptual error at DefaultPPD, 0 for equal images
+	FLIP float64
+	// MaxDiff is the largest absolute channel difference
+	MaxDiff float64
+	// Differing counts the pixels with any channel differing
+	Differing, Pixels int
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("MSE %.6g, PSNR %.2f dB, SSIM %.4f, FLIP %.4f, max diff %.4f, %d of %d pixels differ",
+		r.MSE, r.PSNR, r.SSIM, r.FLIP, r.MaxDiff, r.Differing, r.Pixels)
+}
+
+// Load decodes the PNG, JPEG or GIF image at filePath
+func Load(filePath string) (image.Image, error) {
+	inReader, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer inReader.Close()
+
+	img, _, err := image.Decode(inReader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+	return img, nil
+}
+
+// rgb returns the channels of pixel (x, y) in [0, 1], relative to the
+// top left corner of img
+func rgb(img image.Image, x, y int) (r, g, b float64) {
+	min := img.Bounds().Min
+	cr, cg, cb, _ := img.At(min.X+x, min.Y+y).RGBA()
+	return float64(cr) / 0xffff, float64(cg) / 0xffff, float64(cb) / 0xffff
+}
+
+func sameSize(a, b image.Image) error {
+	if a.Bounds().Size() != b.Bounds().Size() {
+		return fmt.Errorf("image sizes differ: %v and %v", a.Bounds().Size(), b.Bounds().Size())
+	}
+	return nil
+}
+
+// Compare computes the difference metrics between a and b
+func Compare(a, b image.Image) (Report, error) {
+	if err := sameSize(a, b); err != nil {
+		return Report{}, err
+	}
+	size := a.Bounds().Size()
+	r := Report{Pixels: size.X * size.Y}
+
+	var sum float64
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			ar, ag, ab := rgb(a, x, y)
+			br, bg, bb := rgb(b, x, y)
+			differs := false
+			for _, d := range [3]float64{ar - br, ag - bg, ab - bb} {
+				sum += d * d
+				r.MaxDiff = math.Max(r.MaxDiff, math.Abs(d))
+				differs = differs || d != 0
+			}
+			if differs {
+				r.Differing++
+			}
+		}
+	}
+	r.MSE = sum / float64(3*r.Pixels)
+	r.PSNR = PSNR(r.MSE)
+
+	var err error
+	if r.SSIM, err = SSIM(a, b); err != nil {
+		return r, err
+	}
+	flip, err := FLIP(a, b, DefaultPPD)
+	if err != nil {
+		return r, err
+	}
+	r.FLIP = flip.Mean()
+	return r, nil
+}
+
+// PSNR converts a mean squared error of [0, 1] values to decibels
+func PSNR(mse float64) float64 {
+	if mse == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(1/mse)
+}
+
+// DiffImage visualizes the absolute per-channel difference of a and b,
+// multiplied by gain so that small errors become visible
+func DiffImage(a, b image.Image, gain float64) (*image.RGBA, error) {
+	if err := sameSize(a, b); err != nil {
+		return nil, err
+	}
+	size := a.Bounds().Size()
+	out := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	channel := func(d float64) uint8 {
+		return uint8(math.Min(1, math.Abs(d)*gain) * 255)
+	}
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			ar, ag, ab := rgb(a, x, y)
+			br, bg, bb := rgb(b, x, y)
+			out.SetRGBA(x, y, color.RGBA{channel(ar - br), channel(ag - bg), channel(ab - bb), 255})
+		}
+	}
+	return out, nil
+}
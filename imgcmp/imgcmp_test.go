@@ -0,0 +1,78 @@
+package imgcmp
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// gradient draws a horizontal ramp with a square of value square
+func gradient(square uint8) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			v := uint8(x * 8)
+			if x >= 12 && x < 20 && y >= 12 && y < 20 {
+				v = square
+			}
+			img.SetRGBA(x, y, color.RGBA{v, v / 2, 255 - v, 255})
+		}
+	}
+	return img
+}
+
+func TestCompareEqual(t *testing.T) {
+	r, err := Compare(gradient(0), gradient(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.MSE != 0 || !math.IsInf(r.PSNR, 1) || math.Abs(r.SSIM-1) > 1e-9 || r.FLIP != 0 || r.Differing != 0 {
+		t.Errorf("equal images: %v", r)
+	}
+}
+
+func TestCompareOrdersDifferences(t *testing.T) {
+	small, err := Compare(gradient(0), gradient(40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	large, err := Compare(gradient(0), gradient(255))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if small.Differing != 64 {
+		t.Errorf("%d pixels differ, expect 64", small.Differing)
+	}
+	if !(small.MSE < large.MSE && small.PSNR > large.PSNR && small.SSIM > large.SSIM && small.FLIP < large.FLIP) {
+		t.Errorf("expect every metric to rank %v closer than %v", small, large)
+	}
+	if large.SSIM >= 1 || large.FLIP <= 0 {
+		t.Errorf("different images: %v", large)
+	}
+}
+
+func TestFLIPHeatmap(t *testing.T) {
+	m, err := FLIP(gradient(0), gradient(255), DefaultPPD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range m.Err {
+		if e < 0 || e > 1 {
+			t.Fatalf("error %g out of [0, 1]", e)
+		}
+	}
+	// the changed square is the worst, its surroundings only see the blur
+	if m.Err[16*32+16] <= m.Err[0] {
+		t.Errorf("error %g at the square, %g in the corner", m.Err[16*32+16], m.Err[0])
+	}
+	if got := m.Heatmap().Bounds(); got != image.Rect(0, 0, 32, 32) {
+		t.Errorf("heatmap bounds %v", got)
+	}
+}
+
+func TestSizeMismatch(t *testing.T) {
+	if _, err := Compare(gradient(0), image.NewRGBA(image.Rect(0, 0, 8, 8))); err == nil {
+		t.Error("expect an error for images of different sizes")
+	}
+}
@@ -0,0 +1,125 @@
+package imgcmp
+
+import (
+	"image"
+	"math"
+)
+
+// plane is one channel of an image, row by row from the top
+type plane struct {
+	w, h int
+	v    []float64
+}
+
+func newPlane(w, h int) *plane {
+	return &plane{w, h, make([]float64, w*h)}
+}
+
+// planes splits img into planes computed from the [0, 1] channels of each
+// pixel by f
+func planes(img image.Image, n int, f func(r, g, b float64) []float64) []*plane {
+	size := img.Bounds().Size()
+	ps := make([]*plane, n)
+	for i := range ps {
+		ps[i] = newPlane(size.X, size.Y)
+	}
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			for i, v := range f(rgb(img, x, y)) {
+				ps[i].v[y*size.X+x] = v
+			}
+		}
+	}
+	return ps
+}
+
+// mul returns the product of p and q pixel by pixel
+func (p *plane) mul(q *plane) *plane {
+	out := newPlane(p.w, p.h)
+	for i := range p.v {
+		out.v[i] = p.v[i] * q.v[i]
+	}
+	return out
+}
+
+// convolve filters p by the separable kernel kx along rows then ky along
+// columns, both of odd length, edges are clamped
+func (p *plane) convolve(kx, ky []float64) *plane {
+	tmp, out := newPlane(p.w, p.h), newPlane(p.w, p.h)
+	clamp := func(v, max int) int {
+		if v < 0 {
+			return 0
+		}
+		if v >= max {
+			return max - 1
+		}
+		return v
+	}
+	rx, ry := len(kx)/2, len(ky)/2
+	for y := 0; y < p.h; y++ {
+		for x := 0; x < p.w; x++ {
+			var sum float64
+			for i, k := range kx {
+				sum += k * p.v[y*p.w+clamp(x+i-rx, p.w)]
+			}
+			tmp.v[y*p.w+x] = sum
+		}
+	}
+	for y := 0; y < p.h; y++ {
+		for x := 0; x < p.w; x++ {
+			var sum float64
+			for i, k := range ky {
+				sum += k * tmp.v[clamp(y+i-ry, p.h)*p.w+x]
+			}
+			out.v[y*p.w+x] = sum
+		}
+	}
+	return out
+}
+
+// gaussian returns a normalized kernel of deviation sigma pixels, cut at
+// 3 sigma
+func gaussian(sigma float64) []float64 {
+	r := int(math.Ceil(3 * sigma))
+	k := make([]float64, 2*r+1)
+	var sum float64
+	for i := range k {
+		x := float64(i - r)
+		k[i] = math.Exp(-x * x / (2 * sigma * sigma))
+		sum += k[i]
+	}
+	for i := range k {
+		k[i] /= sum
+	}
+	return k
+}
+
+// luma of gamma encoded channels, Rec. 709 weights
+func luma(r, g, b float64) []float64 {
+	return []float64{0.2126*r + 0.7152*g + 0.0722*b}
+}
+
+// SSIM returns the mean structural similarity of the luma of a and b, with
+// the usual gaussian window of 1.5 pixels, 1 for equal images
+func SSIM(a, b image.Image) (float64, error) {
+	if err := sameSize(a, b); err != nil {
+		return 0, err
+	}
+	const (
+		c1 = 0.01 * 0.01
+		c2 = 0.03 * 0.03
+	)
+	pa, pb := planes(a, 1, luma)[0], planes(b, 1, luma)[0]
+	k := gaussian(1.5)
+	blur := func(p *plane) *plane { return p.convolve(k, k) }
+	muA, muB := blur(pa), blur(pb)
+	aa, bb, ab := blur(pa.mul(pa)), blur(pb.mul(pb)), blur(pa.mul(pb))
+
+	var sum float64
+	for i := range pa.v {
+		ma, mb := muA.v[i], muB.v[i]
+		varA, varB, cov := aa.v[i]-ma*ma, bb.v[i]-mb*mb, ab.v[i]-ma*mb
+		sum += (2*ma*mb + c1) * (2*cov + c2) / ((ma*ma + mb*mb + c1) * (varA + varB + c2))
+	}
+	return sum / float64(len(pa.v)), nil
+}
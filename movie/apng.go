@@ -0,0 +1,211 @@
+package movie
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+)
+
+// pngSignature starts every PNG file
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// EncodeAPNG writes frames as an animated PNG. The first frame stays the
+// default image shown by viewers without APNG support.
+func EncodeAPNG(w io.Writer, frames []image.Image, o Options) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frame to encode")
+	}
+	size := frames[0].Bounds().Size()
+
+	// every frame shares the header, so its format is RGB only when all
+	// of them are opaque
+	rgba := make([]*image.NRGBA, len(frames))
+	colorType := byte(2)
+	for i, f := range frames {
+		if f.Bounds().Size() != size {
+			return fmt.Errorf("frame %d is %v, expect %v like the first frame", i, f.Bounds().Size(), size)
+		}
+		rgba[i] = image.NewNRGBA(image.Rect(0, 0, size.X, size.Y))
+		draw.Draw(rgba[i], rgba[i].Bounds(), f, f.Bounds().Min, draw.Src)
+		if !rgba[i].Opaque() {
+			colorType = 6
+		}
+	}
+	header := append(be32(uint32(size.X), uint32(size.Y)), 8, colorType, 0, 0, 0)
+	data := make([][]byte, len(frames))
+	for i, img := range rgba {
+		var err error
+		if data[i], err = imageData(img, colorType == 6); err != nil {
+			return err
+		}
+	}
+
+	cw := &chunkWriter{w: w}
+	io.WriteString(cw, pngSignature)
+	cw.chunk("IHDR", header)
+	cw.chunk("acTL", be32(uint32(len(frames)), uint32(o.Loops)))
+	seq := uint32(0)
+	for i := range frames {
+		control := be32(seq, uint32(size.X), uint32(size.Y), 0, 0)
+		// delay, then dispose and blend operations: none and source, every
+		// frame covers the whole image
+		control = append(control, be16(o.apngDelay())...)
+		control = append(control, 0, 0)
+		cw.chunk("fcTL", control)
+		seq++
+		if i == 0 {
+			cw.chunk("IDAT", data[i])
+			continue
+		}
+		cw.chunk("fdAT", append(be32(seq), data[i]...))
+		seq++
+	}
+	cw.chunk("IEND", nil)
+	return cw.err
+}
+
+// apngDelay returns the numerator and denominator in seconds of the time a
+// frame is shown, in the finest unit down from milliseconds where it fits
+// 16 bits
+func (o Options) apngDelay() (num, den uint16) {
+	for den = 1000; den > 1; den /= 10 {
+		if 1/o.FPS*float64(den) < math.MaxUint16 {
+			break
+		}
+	}
+	return uint16(o.delay(1 / float64(den))), den
+}
+
+// imageData filters the rows of img, 8 bit RGB or RGBA, and compresses
+// them into the content of its IDAT chunks
+func imageData(img *image.NRGBA, alpha bool) ([]byte, error) {
+	bpp := 3
+	if alpha {
+		bpp = 4
+	}
+	width := img.Rect.Dx()
+	prev, cur := make([]byte, bpp*width), make([]byte, bpp*width)
+	var filtered [5][]byte
+	for f := range filtered {
+		filtered[f] = make([]byte, 1+bpp*width)
+		filtered[f][0] = byte(f)
+	}
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	for y := 0; y < img.Rect.Dy(); y++ {
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < width; x++ {
+			copy(cur[bpp*x:bpp*x+bpp], row[4*x:4*x+4])
+		}
+		if _, err := zw.Write(filterRow(filtered, cur, prev, bpp)); err != nil {
+			return nil, err
+		}
+		prev, cur = cur, prev
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// filterRow applies the five PNG filters to cur, prev being the row above,
+// and returns the one of least absolute sum like image/png does
+func filterRow(filtered [5][]byte, cur, prev []byte, bpp int) []byte {
+	best, bestSum := 0, -1
+	for f := range filtered {
+		out := filtered[f][1:]
+		sum := 0
+		for i, c := range cur {
+			var a, b, d byte
+			if i >= bpp {
+				a, d = cur[i-bpp], prev[i-bpp]
+			}
+			b = prev[i]
+			switch f {
+			case 0:
+				out[i] = c
+			case 1:
+				out[i] = c - a
+			case 2:
+				out[i] = c - b
+			case 3:
+				out[i] = c - byte((int(a)+int(b))/2)
+			case 4:
+				out[i] = c - paeth(a, b, d)
+			}
+			sum += abs(int(int8(out[i])))
+		}
+		if bestSum < 0 || sum < bestSum {
+			best, bestSum = f, sum
+		}
+	}
+	return filtered[best]
+}
+
+// paeth predicts a byte from its left a, upper b and upper left c
+// neighbours
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// chunkWriter writes PNG chunks, keeping the first error
+type chunkWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.err = err
+	return n, err
+}
+
+func (cw *chunkWriter) chunk(kind string, data []byte) {
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(kind))
+	crc.Write(data)
+	cw.Write(be32(uint32(len(data))))
+	io.WriteString(cw, kind)
+	cw.Write(data)
+	cw.Write(be32(crc.Sum32()))
+}
+
+func be32(values ...uint32) []byte {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint32(b[4*i:], v)
+	}
+	return b
+}
+
+func be16(values ...uint16) []byte {
+	b := make([]byte, 2*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint16(b[2*i:], v)
+	}
+	return b
+}
@@ -0,0 +1,91 @@
+package movie
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Dither tells how colors missing from a palette are approximated
+type Dither int
+
+const (
+	// NoDither takes the nearest palette color, showing bands
+	NoDither Dither = iota
+	// FloydSteinberg diffuses the error of every pixel to its neighbours
+	FloydSteinberg
+	// Ordered adds a Bayer threshold pattern, stable from frame to frame
+	Ordered
+)
+
+// DitherNames lists the dithering methods of ParseDither
+var DitherNames = []string{"none", "floyd-steinberg", "ordered"}
+
+// ParseDither returns the dithering method called name
+func ParseDither(name string) (Dither, error) {
+	for i, n := range DitherNames {
+		if n == name {
+			return Dither(i), nil
+		}
+	}
+	return NoDither, fmt.Errorf("unknown dithering %q", name)
+}
+
+// bayer8 is the 8x8 Bayer threshold matrix
+var bayer8 = [8][8]int{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// orderedSpread is the amplitude of the ordered dithering pattern, in
+// 8 bit channel steps
+const orderedSpread = 32
+
+// Paletted converts img to palette p with the dithering method d
+func Paletted(img image.Image, p color.Palette, d Dither) *image.Paletted {
+	b := img.Bounds()
+	out := image.NewPaletted(image.Rect(0, 0, b.Dx(), b.Dy()), p)
+	switch d {
+	case FloydSteinberg:
+		draw.FloydSteinberg.Draw(out, out.Bounds(), img, b.Min)
+		return out
+	case Ordered:
+		// the palette search is the slow part, most pixels repeat
+		cache := map[color.RGBA]uint8{}
+		for y := 0; y < b.Dy(); y++ {
+			for x := 0; x < b.Dx(); x++ {
+				c := color.RGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.RGBA)
+				offset := (bayer8[y%8][x%8]*2 - 63) * orderedSpread / 128
+				c = color.RGBA{shift(c.R, offset), shift(c.G, offset), shift(c.B, offset), 255}
+				index, ok := cache[c]
+				if !ok {
+					index = uint8(p.Index(c))
+					cache[c] = index
+				}
+				out.SetColorIndex(x, y, index)
+			}
+		}
+		return out
+	}
+	draw.Draw(out, out.Bounds(), img, b.Min, draw.Src)
+	return out
+}
+
+// shift adds offset to the channel v, clamped to [0, 255]
+func shift(v uint8, offset int) uint8 {
+	s := int(v) + offset
+	switch {
+	case s < 0:
+		return 0
+	case s > 255:
+		return 255
+	}
+	return uint8(s)
+}
@@ -0,0 +1,108 @@
+package movie
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Options configures the encoding of an animation
+type Options struct {
+	// FPS is the number of frames per second
+	FPS float64
+	// Loops is the number of times the animation plays, 0 loops forever
+	Loops int
+	// Colors bounds the GIF palette, at most 256
+	Colors int
+	// Dither is how GIF frames approximate the colors of the palette
+	Dither Dither
+}
+
+// DefaultOptions returns 24 frames per second looping forever, with a
+// dithered palette of 256 colors
+func DefaultOptions() Options {
+	return Options{FPS: 24, Colors: 256, Dither: FloydSteinberg}
+}
+
+// delay returns the time a frame is shown, rounded to unit, at most the
+// 65535 units a 16 bit delay holds
+func (o Options) delay(unit float64) int {
+	return int(math.Min(math.Max(1, math.Round(1/(o.FPS*unit))), math.MaxUint16))
+}
+
+// EncodeGIF writes frames as an animated GIF, with one palette shared by
+// all frames so colors do not flicker
+func EncodeGIF(w io.Writer, frames []image.Image, o Options) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frame to encode")
+	}
+	if o.Colors < 2 || o.Colors > 256 {
+		return fmt.Errorf("a GIF palette holds 2 to 256 colors, got %d", o.Colors)
+	}
+	palette := Quantize(frames, o.Colors)
+
+	anim := &gif.GIF{LoopCount: o.Loops}
+	if o.Loops == 1 {
+		// a single play has no loop extension
+		anim.LoopCount = -1
+	} else if o.Loops > 1 {
+		anim.LoopCount = o.Loops - 1
+	}
+	for _, f := range frames {
+		anim.Image = append(anim.Image, Paletted(f, palette, o.Dither))
+		anim.Delay = append(anim.Delay, o.delay(0.01))
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// LoadFrames decodes the PNG, JPEG or GIF images at paths
+func LoadFrames(paths []string) ([]image.Image, error) {
+	frames := make([]image.Image, len(paths))
+	for i, p := range paths {
+		inReader, err := os.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		frames[i], _, err = image.Decode(bufio.NewReader(inReader))
+		inReader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", p, err)
+		}
+	}
+	return frames, nil
+}
+
+// Save writes frames to filePath, as a GIF or, for .png and .apng files,
+// as an APNG
+func Save(filePath string, frames []image.Image, o Options) error {
+	var encode func(io.Writer, []image.Image, Options) error
+	switch ext := strings.ToLower(filepath.Ext(filePath)); ext {
+	case ".gif":
+		encode = EncodeGIF
+	case ".png", ".apng":
+		encode = EncodeAPNG
+	default:
+		return fmt.Errorf("%s: unsupported animation format %q, expect .gif, .png or .apng", filePath, ext)
+	}
+
+	outWriter, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	buffered := bufio.NewWriter(outWriter)
+	if err = encode(buffered, frames, o); err == nil {
+		err = buffered.Flush()
+	}
+	if cerr := outWriter.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,192 @@
+package movie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+)
+
+// testFrames returns n gradients of 37x23 pixels, those listed in
+// transparent fading out to the right
+func testFrames(n int, transparent ...int) []image.Image {
+	frames := make([]image.Image, n)
+	for i := range frames {
+		img := image.NewNRGBA(image.Rect(0, 0, 37, 23))
+		for y := 0; y < 23; y++ {
+			for x := 0; x < 37; x++ {
+				img.SetNRGBA(x, y, color.NRGBA{uint8(x * 7 * (i + 1)), uint8(y * 11), uint8(x * y), 255})
+			}
+		}
+		for _, t := range transparent {
+			if t == i {
+				for y := 0; y < 23; y++ {
+					for x := 0; x < 37; x++ {
+						img.Pix[img.PixOffset(x, y)+3] = uint8(255 - 6*x)
+					}
+				}
+			}
+		}
+		frames[i] = img
+	}
+	return frames
+}
+
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+func splitChunks(t *testing.T, b []byte) []pngChunk {
+	if !bytes.HasPrefix(b, []byte(pngSignature)) {
+		t.Fatal("missing PNG signature")
+	}
+	b = b[len(pngSignature):]
+	var chunks []pngChunk
+	for len(b) >= 12 {
+		n := binary.BigEndian.Uint32(b)
+		chunks = append(chunks, pngChunk{string(b[4:8]), b[8 : 8+n]})
+		b = b[12+n:]
+	}
+	if len(b) != 0 {
+		t.Fatal("truncated chunk")
+	}
+	return chunks
+}
+
+// decodeFrame decodes the image data of one frame as a still PNG
+func decodeFrame(t *testing.T, header, data []byte) image.Image {
+	var buf bytes.Buffer
+	cw := &chunkWriter{w: &buf}
+	cw.Write([]byte(pngSignature))
+	cw.chunk("IHDR", header)
+	cw.chunk("IDAT", data)
+	cw.chunk("IEND", nil)
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func sameImage(a, b image.Image) bool {
+	for y := a.Bounds().Min.Y; y < a.Bounds().Max.Y; y++ {
+		for x := a.Bounds().Min.X; x < a.Bounds().Max.X; x++ {
+			if color.NRGBAModel.Convert(a.At(x, y)) != color.NRGBAModel.Convert(b.At(x, y)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestEncodeAPNG(t *testing.T) {
+	cases := []struct {
+		name      string
+		frames    []image.Image
+		colorType byte
+	}{
+		{"opaque", testFrames(3), 2},
+		{"some transparent", testFrames(4, 1), 6},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodeAPNG(&buf, c.frames, DefaultOptions()); err != nil {
+				t.Fatal(err)
+			}
+			// viewers without APNG support show the first frame
+			still, err := png.Decode(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !sameImage(still, c.frames[0]) {
+				t.Error("the default image is not the first frame")
+			}
+
+			var header []byte
+			var frames []image.Image
+			seq := uint32(0)
+			for _, ch := range splitChunks(t, buf.Bytes()) {
+				switch ch.kind {
+				case "IHDR":
+					header = ch.data
+					if header[9] != c.colorType {
+						t.Errorf("color type %d, expect %d", header[9], c.colorType)
+					}
+				case "acTL":
+					if n := binary.BigEndian.Uint32(ch.data); n != uint32(len(c.frames)) {
+						t.Errorf("acTL counts %d frames, expect %d", n, len(c.frames))
+					}
+				case "fcTL", "fdAT":
+					if n := binary.BigEndian.Uint32(ch.data); n != seq {
+						t.Errorf("%s sequence number %d, expect %d", ch.kind, n, seq)
+					}
+					seq++
+					if ch.kind == "fdAT" {
+						frames = append(frames, decodeFrame(t, header, ch.data[4:]))
+					}
+				case "IDAT":
+					frames = append(frames, decodeFrame(t, header, ch.data))
+				}
+			}
+			if len(frames) != len(c.frames) {
+				t.Fatalf("%d frames, expect %d", len(frames), len(c.frames))
+			}
+			for i := range frames {
+				if !sameImage(frames[i], c.frames[i]) {
+					t.Errorf("frame %d differs", i)
+				}
+			}
+		})
+	}
+}
+
+func TestAPNGDelay(t *testing.T) {
+	cases := []struct {
+		fps      float64
+		num, den uint16
+	}{
+		{24, 42, 1000},
+		{0.5, 2000, 1000},
+		// 100 s do not fit in milliseconds
+		{0.01, 10000, 100},
+		{1e-5, 65535, 1},
+		{1e-9, 65535, 1},
+	}
+	for _, c := range cases {
+		o := DefaultOptions()
+		o.FPS = c.fps
+		if num, den := o.apngDelay(); num != c.num || den != c.den {
+			t.Errorf("%g fps: delay %d/%d, expect %d/%d", c.fps, num, den, c.num, c.den)
+		}
+	}
+}
+
+func TestEncodeGIF(t *testing.T) {
+	o := DefaultOptions()
+	o.FPS, o.Loops = 10, 3
+	frames := testFrames(3)
+	var buf bytes.Buffer
+	if err := EncodeGIF(&buf, frames, o); err != nil {
+		t.Fatal(err)
+	}
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Image) != len(frames) {
+		t.Fatalf("%d frames, expect %d", len(g.Image), len(frames))
+	}
+	for i, d := range g.Delay {
+		if d != 10 {
+			t.Errorf("frame %d shows for %d hundredths, expect 10", i, d)
+		}
+	}
+	if g.LoopCount != 2 {
+		t.Errorf("loop count %d, expect 2 repeats for 3 plays", g.LoopCount)
+	}
+}
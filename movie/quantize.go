@@ -0,0 +1,119 @@
+package movie
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// maxQuantizeSamples bounds the pixels median cut looks at, frames are
+// subsampled evenly beyond it
+const maxQuantizeSamples = 1 << 18
+
+// Quantize picks a palette of at most n colors for all frames by median
+// cut: the box of colors with the widest channel is split at its median
+// until there are n boxes, each giving its mean color
+func Quantize(frames []image.Image, n int) color.Palette {
+	var total int
+	for _, f := range frames {
+		total += f.Bounds().Dx() * f.Bounds().Dy()
+	}
+	step := 1
+	if total > maxQuantizeSamples {
+		step = (total + maxQuantizeSamples - 1) / maxQuantizeSamples
+	}
+
+	var pixels [][3]uint8
+	i := 0
+	for _, f := range frames {
+		b := f.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if i%step == 0 {
+					c := color.RGBAModel.Convert(f.At(x, y)).(color.RGBA)
+					pixels = append(pixels, [3]uint8{c.R, c.G, c.B})
+				}
+				i++
+			}
+		}
+	}
+	if len(pixels) == 0 {
+		return color.Palette{color.Black}
+	}
+
+	boxes := []colorBox{newColorBox(pixels)}
+	for len(boxes) < n {
+		// split the box with the widest channel range
+		widest, channel, span := -1, 0, 0
+		for i, b := range boxes {
+			if len(b.pixels) < 2 {
+				continue
+			}
+			if c, s := b.widest(); s > span {
+				widest, channel, span = i, c, s
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		lo, hi := boxes[widest].split(channel)
+		boxes[widest] = lo
+		boxes = append(boxes, hi)
+	}
+
+	palette := make(color.Palette, len(boxes))
+	for i, b := range boxes {
+		palette[i] = b.mean()
+	}
+	return palette
+}
+
+// colorBox holds the pixels of one cell of the color space
+type colorBox struct {
+	pixels [][3]uint8
+	min    [3]uint8
+	max    [3]uint8
+}
+
+func newColorBox(pixels [][3]uint8) colorBox {
+	b := colorBox{pixels: pixels, min: [3]uint8{255, 255, 255}}
+	for _, p := range pixels {
+		for c := 0; c < 3; c++ {
+			if p[c] < b.min[c] {
+				b.min[c] = p[c]
+			}
+			if p[c] > b.max[c] {
+				b.max[c] = p[c]
+			}
+		}
+	}
+	return b
+}
+
+// widest returns the channel with the largest range and that range
+func (b colorBox) widest() (channel, span int) {
+	for c := 0; c < 3; c++ {
+		if s := int(b.max[c]) - int(b.min[c]); s > span {
+			channel, span = c, s
+		}
+	}
+	return channel, span
+}
+
+// split cuts the box at the median of channel
+func (b colorBox) split(channel int) (colorBox, colorBox) {
+	sort.Slice(b.pixels, func(i, j int) bool { return b.pixels[i][channel] < b.pixels[j][channel] })
+	median := len(b.pixels) / 2
+	return newColorBox(b.pixels[:median]), newColorBox(b.pixels[median:])
+}
+
+func (b colorBox) mean() color.Color {
+	var sum [3]int
+	for _, p := range b.pixels {
+		for c := 0; c < 3; c++ {
+			sum[c] += int(p[c])
+		}
+	}
+	n := len(b.pixels)
+	return color.RGBA{uint8(sum[0] / n), uint8(sum[1] / n), uint8(sum[2] / n), 255}
+}
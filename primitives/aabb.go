@@ -0,0 +1,39 @@
+package primitives
+
+import (
+	"math"
+
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// AABB is an axis aligned bounding box, Min > Max on any axis means empty
+type AABB struct {
+	Min, Max vec3.Vec3
+}
+
+// EmptyAABB returns a box that contains nothing, the identity of Union
+func EmptyAABB() AABB {
+	inf := math.Inf(1)
+	return AABB{
+		Min: vec3.Vec3{X: inf, Y: inf, Z: inf},
+		Max: vec3.Vec3{X: -inf, Y: -inf, Z: -inf},
+	}
+}
+
+// IsEmpty reports whether the box contains no point
+func (b AABB) IsEmpty() bool {
+	return b.Min.X > b.Max.X || b.Min.Y > b.Max.Y || b.Min.Z > b.Max.Z
+}
+
+// Union returns the smallest box containing both b and o
+func (b AABB) Union(o AABB) AABB {
+	return AABB{
+		Min: vec3.Vec3{X: math.Min(b.Min.X, o.Min.X), Y: math.Min(b.Min.Y, o.Min.Y), Z: math.Min(b.Min.Z, o.Min.Z)},
+		Max: vec3.Vec3{X: math.Max(b.Max.X, o.Max.X), Y: math.Max(b.Max.Y, o.Max.Y), Z: math.Max(b.Max.Z, o.Max.Z)},
+	}
+}
+
+// Size returns the extent of the box along every axis
+func (b AABB) Size() *vec3.Vec3 {
+	return b.Max.Sub(&b.Min)
+}
@@ -0,0 +1,96 @@
+package primitives
+
+import (
+	"math"
+
+	"github.com/Oaklight/Rayerson/ray"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// BSDF evaluates the scattering Bounce samples. Directions are unit and
+// point away from the surface: wo back where the ray came from, wi where
+// Bounce sends it. n is the unit normal Bounce is given.
+type BSDF interface {
+	// Eval returns the BSDF of light arriving along wi and leaving along
+	// wo, 0 for the specular part
+	Eval(wo, wi, n *vec3.Vec3) *ray.Color
+	// PDF returns the density per solid angle of Bounce choosing wi, 0 for
+	// specular directions
+	PDF(wo, wi, n *vec3.Vec3) float64
+}
+
+var (
+	_ BSDF = (*DiffuseMaterial)(nil)
+	_ BSDF = (*MetallicMaterial)(nil)
+	_ BSDF = (*DielectricMaterial)(nil)
+)
+
+// ========================= DiffuseMaterial =========================
+
+// Eval is Lambert's albedo / pi above the surface
+func (l *DiffuseMaterial) Eval(wo, wi, n *vec3.Vec3) *ray.Color {
+	if wi.Dot(n) <= 0 || wo.Dot(n) <= 0 {
+		return &ray.Opaque
+	}
+	return l.Albedo.MulScalar(1 / math.Pi)
+}
+
+// PDF is cos / pi: the normal plus a point of the unit sphere is a point of
+// the sphere touching the surface, seen from the surface as cosine lobe
+func (l *DiffuseMaterial) PDF(wo, wi, n *vec3.Vec3) float64 {
+	return math.Max(0, wi.Dot(n)) / math.Pi
+}
+
+// ========================= MetallicMaterial =========================
+
+// Eval returns what makes every bounce weigh the albedo: albedo times the
+// PDF over the cosine of wi. Its lobe being sampled with a constant weight,
+// swapping wo and wi only keeps the PDF, so this BSDF is not reciprocal
+// when Fuzz is above 0.
+func (m *MetallicMaterial) Eval(wo, wi, n *vec3.Vec3) *ray.Color {
+	cosine := wi.Dot(n)
+	if cosine <= 0 {
+		return &ray.Opaque
+	}
+	return m.Albedo.MulScalar(m.PDF(wo, wi, n) / cosine)
+}
+
+// PDF adds the density of the fuzzed direction along wi to that of its
+// mirror below the surface, folded back by Bounce. A direction d meets the
+// sphere of radius Fuzz around the mirror direction R at t, each point of
+// it covering 1 / (4 pi Fuzz^2) of the area, seen from the surface under
+// t^2 / (Fuzz |cos|) with |cos| = sqrt((d.R)^2 - 1 + Fuzz^2) / Fuzz.
+func (m *MetallicMaterial) PDF(wo, wi, n *vec3.Vec3) float64 {
+	if m.Fuzz <= 0 || wi.Dot(n) <= 0 || wo.Dot(n) <= 0 {
+		return 0
+	}
+	reflected := wo.Negate().Reflect(n)
+	f := m.Fuzz
+	density := func(d *vec3.Vec3) float64 {
+		cosine := d.Dot(reflected)
+		disc := cosine*cosine - 1 + f*f
+		if disc <= 0 {
+			return 0
+		}
+		var pdf float64
+		for _, t := range []float64{cosine - math.Sqrt(disc), cosine + math.Sqrt(disc)} {
+			if t > 0 {
+				pdf += t * t / (4 * math.Pi * f * math.Sqrt(disc))
+			}
+		}
+		return pdf
+	}
+	return density(wi) + density(wi.Reflect(n))
+}
+
+// ========================= DielectricMaterial =========================
+
+// Eval is 0, glass only reflects and refracts specularly
+func (d *DielectricMaterial) Eval(wo, wi, n *vec3.Vec3) *ray.Color {
+	return &ray.Opaque
+}
+
+// PDF is 0, both directions Bounce picks are specular
+func (d *DielectricMaterial) PDF(wo, wi, n *vec3.Vec3) float64 {
+	return 0
+}
@@ -0,0 +1,304 @@
+package primitives
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/Oaklight/Rayerson/ray"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// lobes are the materials with a BSDF to evaluate, not only specular
+// directions
+func lobes() []Materials {
+	gray := ray.NewColor(0.7, 0.7, 0.7)
+	return []Materials{
+		NewDiffuse(gray),
+		NewMetallic(gray, 0.2),
+		NewMetallic(gray, 0.6),
+		NewMetallic(gray, 1),
+	}
+}
+
+func materialName(m Materials) string {
+	if metal, ok := m.(*MetallicMaterial); ok {
+		return fmt.Sprintf("metal fuzz %g", metal.Fuzz)
+	}
+	return fmt.Sprintf("%T", m)
+}
+
+var up = &vec3.Vec3{Z: 1}
+
+// chiSquareLimit approximates the 99.99th percentile of the chi-square
+// distribution with df degrees of freedom (Wilson-Hilferty)
+func chiSquareLimit(df int) float64 {
+	k, z := float64(df), 3.719
+	h := 2 / (9 * k)
+	return k * math.Pow(1-h+z*math.Sqrt(h), 3)
+}
+
+// outgoing returns the direction back to the viewer at theta degrees off
+// the normal
+func outgoing(theta float64) *vec3.Vec3 {
+	rad := theta * math.Pi / 180
+	return &vec3.Vec3{X: math.Sin(rad), Z: math.Cos(rad)}
+}
+
+// direction returns the unit vector of the hemisphere around up at
+// cos(theta) and phi
+func direction(cosine, phi float64) *vec3.Vec3 {
+	sine := math.Sqrt(math.Max(0, 1-cosine*cosine))
+	return &vec3.Vec3{X: sine * math.Cos(phi), Y: sine * math.Sin(phi), Z: cosine}
+}
+
+// bounce samples the direction m sends a ray arriving opposite wo
+func bounce(m Materials, wo *vec3.Vec3, src randSource) *vec3.Vec3 {
+	r := ray.NewRay(wo.MulScalar(2), wo.Negate())
+	out := m.Bounce(r, &Hit{Point: &vec3.Vec3{}, Normal: up, Materials: m}, src)
+	if out == nil {
+		return nil
+	}
+	return out.Direct.Normalize()
+}
+
+// point is a direction Bounce may choose and the probability of choosing
+// it, or a neighbourhood of it
+type point struct {
+	w      *vec3.Vec3
+	weight float64
+}
+
+// frame returns two unit vectors orthogonal to axis and to each other
+func frame(axis *vec3.Vec3) (*vec3.Vec3, *vec3.Vec3) {
+	other := &vec3.Vec3{X: 1}
+	if math.Abs(axis.X) > 0.9 {
+		other = &vec3.Vec3{Y: 1}
+	}
+	u := other.Cross(axis).Normalize()
+	return u, axis.Cross(u)
+}
+
+// quadrature spreads the PDF of m for rays leaving along wo over a grid of
+// directions. The diffuse lobe is smooth around the normal. The metal lobe
+// is integrated around the mirror direction R, where its density is that
+// of the unfolded lobe, PDF(R, d, R). Its rim at cos = sqrt(1 - Fuzz^2) is
+// singular like 1 / sqrt(cos - rim), cos = rim + s^2 smoothes it out. The
+// directions are then folded above the surface like Bounce does.
+func quadrature(m Materials, wo *vec3.Vec3, n int) []point {
+	bsdf := m.(BSDF)
+	axis, rim := up, 0.0
+	density := func(d *vec3.Vec3) float64 { return bsdf.PDF(wo, d, up) }
+	if metal, ok := m.(*MetallicMaterial); ok {
+		axis = wo.Negate().Reflect(up)
+		rim = math.Sqrt(1 - metal.Fuzz*metal.Fuzz)
+		density = func(d *vec3.Vec3) float64 { return bsdf.PDF(axis, d, axis) }
+	}
+	u, v := frame(axis)
+	span := math.Sqrt(1 - rim)
+	ds, dp := span/float64(n), 2*math.Pi/float64(n)
+	points := make([]point, 0, n*n)
+	for i := 0; i < n; i++ {
+		s := (float64(i) + 0.5) * ds
+		cosine := rim + s*s
+		sine := math.Sqrt(math.Max(0, 1-cosine*cosine))
+		for j := 0; j < n; j++ {
+			phi := (float64(j) + 0.5) * dp
+			d := vec3.Add(u.MulScalar(sine*math.Cos(phi)), v.MulScalar(sine*math.Sin(phi)), axis.MulScalar(cosine))
+			weight := density(d) * 2 * s * ds * dp
+			if d.Dot(up) < 0 {
+				d = d.Reflect(up)
+			}
+			points = append(points, point{d, weight})
+		}
+	}
+	return points
+}
+
+// TestPDFIntegratesToOne checks no material loses rays, Bounce always
+// sends them above the surface, and that the folded PDF of the metal
+// adds both halves of its lobe
+func TestPDFIntegratesToOne(t *testing.T) {
+	for _, m := range lobes() {
+		bsdf := m.(BSDF)
+		for _, theta := range []float64{0, 30, 60, 85} {
+			wo := outgoing(theta)
+			var total float64
+			for _, p := range quadrature(m, wo, 400) {
+				total += p.weight
+			}
+			if math.Abs(total-1) > 1e-3 {
+				t.Errorf("%s, %g degrees: PDF integrates to %.5f", materialName(m), theta, total)
+			}
+
+			if _, metal := m.(*MetallicMaterial); !metal {
+				continue
+			}
+			axis := wo.Negate().Reflect(up)
+			for _, p := range quadrature(m, wo, 20) {
+				want := bsdf.PDF(axis, p.w, axis) + bsdf.PDF(axis, p.w.Reflect(up), axis)
+				if got := bsdf.PDF(wo, p.w, up); math.Abs(got-want) > 1e-9*math.Max(1, want) {
+					t.Fatalf("%s, %g degrees: PDF %g along %v, its lobe gives %g", materialName(m), theta, got, *p.w, want)
+				}
+			}
+		}
+	}
+}
+
+// TestBounceFollowsPDF bins the directions of Bounce and compares the
+// counts with the PDF integrated over each bin
+func TestBounceFollowsPDF(t *testing.T) {
+	const n, cosBins, phiBins = 200000, 10, 16
+	bin := func(w *vec3.Vec3) int {
+		phi := math.Atan2(w.Y, w.X) + math.Pi
+		c := int(math.Max(0, math.Min(cosBins-1, w.Z*cosBins)))
+		p := int(math.Min(phiBins-1, phi/(2*math.Pi)*phiBins))
+		return c*phiBins + p
+	}
+	for i, m := range lobes() {
+		for j, theta := range []float64{0, 45, 80} {
+			wo := outgoing(theta)
+			want := make([]float64, cosBins*phiBins)
+			for _, p := range quadrature(m, wo, 800) {
+				want[bin(p.w)] += n * p.weight
+			}
+			src := newRandSource(int64(10*i + j))
+			counts := make([]int, cosBins*phiBins)
+			for k := 0; k < n; k++ {
+				wi := bounce(m, wo, src)
+				if wi == nil || wi.Z < 0 {
+					t.Fatalf("%s, %g degrees: ray lost below the surface", materialName(m), theta)
+				}
+				counts[bin(wi)]++
+			}
+
+			// bins expecting too few rays are pooled
+			var chi2, pooledCount, pooledWant float64
+			df := -1
+			for b, w := range want {
+				got := float64(counts[b])
+				if w < 20 {
+					pooledCount += got
+					pooledWant += w
+					continue
+				}
+				chi2 += (got - w) * (got - w) / w
+				df++
+			}
+			if pooledWant > 0 {
+				chi2 += (pooledCount - pooledWant) * (pooledCount - pooledWant) / math.Max(pooledWant, 20)
+				df++
+			}
+			if limit := chiSquareLimit(df); chi2 > limit {
+				t.Errorf("%s, %g degrees: chi-square %.1f > %.1f over %d bins", materialName(m), theta, chi2, limit, df+1)
+			}
+		}
+	}
+}
+
+// TestBounceWeight checks the weight a bounce carries, the material color,
+// is the BSDF times the cosine over the PDF of its direction
+func TestBounceWeight(t *testing.T) {
+	for i, m := range lobes() {
+		bsdf := m.(BSDF)
+		src := newRandSource(int64(i))
+		for _, theta := range []float64{0, 45, 80} {
+			wo := outgoing(theta)
+			for k := 0; k < 1000; k++ {
+				wi := bounce(m, wo, src)
+				pdf := bsdf.PDF(wo, wi, up)
+				if pdf <= 0 {
+					t.Fatalf("%s, %g degrees: Bounce chose %v of density 0", materialName(m), theta, *wi)
+				}
+				weight := bsdf.Eval(wo, wi, up).MulScalar(wi.Z / pdf)
+				if c := m.Color(); math.Abs(weight.R-c.R) > 1e-9 || math.Abs(weight.G-c.G) > 1e-9 || math.Abs(weight.B-c.B) > 1e-9 {
+					t.Fatalf("%s, %g degrees: weight %v, color %v", materialName(m), theta, *weight, *c)
+				}
+			}
+		}
+	}
+}
+
+// TestReciprocity swaps the directions: the diffuse BSDF and the PDF of
+// the fuzzed metal lobe are symmetric, specular bounces retrace themselves
+func TestReciprocity(t *testing.T) {
+	src := newRandSource(3)
+	for _, m := range lobes() {
+		bsdf := m.(BSDF)
+		for k := 0; k < 2000; k++ {
+			wo := direction(src.Float64(), 2*math.Pi*src.Float64())
+			wi := direction(src.Float64(), 2*math.Pi*src.Float64())
+			if _, metal := m.(*MetallicMaterial); metal {
+				// the weight of the lobe is constant, so only the
+				// density is reciprocal, see MetallicMaterial.Eval
+				if a, b := bsdf.PDF(wo, wi, up), bsdf.PDF(wi, wo, up); math.Abs(a-b) > 1e-9*math.Max(1, a) {
+					t.Fatalf("%s: PDF %g one way, %g the other", materialName(m), a, b)
+				}
+				continue
+			}
+			if a, b := bsdf.Eval(wo, wi, up), bsdf.Eval(wi, wo, up); *a != *b {
+				t.Fatalf("%s: BSDF %v one way, %v the other", materialName(m), *a, *b)
+			}
+		}
+	}
+
+	// the mirror sends the reflection back where it came from
+	mirror := NewMetallic(ray.NewColor(1, 1, 1), 0)
+	wo := outgoing(35)
+	wi := bounce(mirror, wo, src)
+	if back := bounce(mirror, wi, src); back.Sub(wo).Length() > 1e-12 {
+		t.Errorf("mirror: %v reflects to %v, back to %v", *wo, *wi, *back)
+	}
+}
+
+// fixedSource always returns v
+type fixedSource float64
+
+func (s fixedSource) Get1D() float64 {
+	return float64(s)
+}
+
+func (s fixedSource) Get2D() (float64, float64) {
+	return float64(s), float64(s)
+}
+
+// measureReflectance finds the probability of the dielectric to reflect a ray
+// arriving along in on a surface of normal n, the largest choice reflecting
+func measureReflectance(d *DielectricMaterial, in, n *vec3.Vec3) (float64, *vec3.Vec3) {
+	hit := &Hit{Point: &vec3.Vec3{}, Normal: n, Materials: d}
+	r := ray.NewRay(in.Negate(), in)
+	lo, hi := 0.0, 1.0
+	var refracted *vec3.Vec3
+	for i := 0; i < 60; i++ {
+		mid := (lo + hi) / 2
+		out := d.Bounce(r, hit, fixedSource(mid)).Direct
+		if out.Dot(n)*in.Dot(n) > 0 {
+			lo, refracted = mid, out.Normalize()
+		} else {
+			hi = mid
+		}
+	}
+	return lo, refracted
+}
+
+// TestDielectricReciprocity reverses the refracted ray: it must come back
+// along the incident one and be reflected as often
+func TestDielectricReciprocity(t *testing.T) {
+	for _, n := range []float64{1.33, 1.5, 2.4} {
+		glass := NewDielectric(n)
+		for _, theta := range []float64{0, 20, 50, 80} {
+			in := outgoing(theta).Negate()
+			entering, refracted := measureReflectance(glass, in, up)
+			if refracted == nil {
+				t.Fatalf("n %g, %g degrees: never refracted", n, theta)
+			}
+			leaving, back := measureReflectance(glass, refracted.Negate(), up)
+			if math.Abs(entering-leaving) > 1e-9 {
+				t.Errorf("n %g, %g degrees: reflectance %.6f entering, %.6f leaving", n, theta, entering, leaving)
+			}
+			if back == nil || back.Add(in).Length() > 1e-9 {
+				t.Errorf("n %g, %g degrees: the reversed refraction leaves along %v, want %v", n, theta, back, *in.Negate())
+			}
+		}
+	}
+}
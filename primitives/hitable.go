@@ -1,8 +1,8 @@
 package primitives
 
 import (
-	"ray"
-	"vector"
+	"github.com/Oaklight/Rayerson/ray"
+	"github.com/Oaklight/Rayerson/vector"
 )
 
 // Hit contains the scaling factor T along ray direction, and
@@ -13,9 +13,11 @@ type Hit struct {
 	Materials
 }
 
-// Hitable requires all hitable objects to have a Hit function
+// Hitable requires all hitable objects to have a Hit function, and to tell
+// the box they are bound by
 type Hitable interface {
 	Hit(r *ray.Ray, tMin, tMax float64) *Hit
+	Bounds() AABB
 }
 
 // World defines a series of Hitable objects
@@ -39,10 +41,18 @@ func (w *World) Count() int {
 
 // Hit iterates over the world objects and try to hit each one.
 func (w *World) Hit(r *ray.Ray, tMin, tMax float64) *Hit {
+	record, _ := w.HitIndex(r, tMin, tMax)
+	return record
+}
+
+// HitIndex is Hit also returning the index of the object hit, -1 when
+// nothing is
+func (w *World) HitIndex(r *ray.Ray, tMin, tMax float64) (*Hit, int) {
 	closet := tMax
 	var record *Hit
+	index := -1
 
-	for _, each := range *w {
+	for i, each := range *w {
 		if each != nil {
 			// if some node already intersected with a much nearer object,
 			// closet will be updated and that would block anything farther
@@ -50,8 +60,20 @@ func (w *World) Hit(r *ray.Ray, tMin, tMax float64) *Hit {
 			if hit := each.Hit(r, tMin, closet); hit != nil {
 				closet = hit.T
 				record = hit
+				index = i
 			}
 		}
 	}
-	return record
+	return record, index
+}
+
+// Bounds returns the box containing every object of the world
+func (w *World) Bounds() AABB {
+	box := EmptyAABB()
+	for _, each := range *w {
+		if each != nil {
+			box = box.Union(each.Bounds())
+		}
+	}
+	return box
 }
@@ -0,0 +1,131 @@
+package primitives
+
+import (
+	"math"
+
+	"github.com/Oaklight/Rayerson/ray"
+	"github.com/Oaklight/Rayerson/sampling"
+)
+
+// Materials defines the interface type of different materials, Bounce
+// draws whatever random decisions it needs from src
+type Materials interface {
+	Bounce(r *ray.Ray, hit *Hit, src sampling.Source) *ray.Ray
+	Color() *ray.Color
+}
+
+// ========================= DiffuseMaterial =========================
+
+// DiffuseMaterial type
+type DiffuseMaterial struct {
+	Albedo *ray.Color
+}
+
+func NewDiffuse(color *ray.Color) *DiffuseMaterial {
+	return &DiffuseMaterial{color}
+}
+
+func (l *DiffuseMaterial) Color() *ray.Color {
+	return l.Albedo
+}
+
+func (l *DiffuseMaterial) Bounce(r *ray.Ray, hit *Hit, src sampling.Source) *ray.Ray {
+	scattered := hit.Normal.Add(sampling.UniformSphere(src.Get2D()))
+	return ray.NewRay(hit.Point, scattered)
+}
+
+// ========================= MetallicMaterial =========================
+
+// MetallicMaterial type
+type MetallicMaterial struct {
+	Albedo *ray.Color
+	Fuzz   float64
+}
+
+func NewMetallic(color *ray.Color, fuzziness float64) *MetallicMaterial {
+	return &MetallicMaterial{
+		Albedo: color,
+		Fuzz:   math.Min(fuzziness, 1),
+	}
+}
+
+func (m *MetallicMaterial) Color() *ray.Color {
+	return m.Albedo
+}
+
+// Bounce reflects r off the surface, blurred by a sphere of radius Fuzz
+// around the mirror direction. Fuzzed directions going below the surface
+// are mirrored back above it rather than absorbed, so the reflected energy
+// is always the albedo.
+func (m *MetallicMaterial) Bounce(r *ray.Ray, hit *Hit, src sampling.Source) *ray.Ray {
+	reflected := r.Direct.Normalize().Reflect(hit.Normal)
+	// always consume the sample so later bounces keep their dimensions
+	fuzz := sampling.UniformSphere(src.Get2D()).MulScalar(m.Fuzz)
+	if reflected.Dot(hit.Normal) <= 0 {
+		// the ray comes from inside the object
+		return nil
+	}
+	fuzzed := reflected.Add(fuzz)
+	if cosine := fuzzed.Dot(hit.Normal); cosine < 0 {
+		fuzzed = fuzzed.Reflect(hit.Normal)
+	} else if cosine == 0 {
+		fuzzed = reflected
+	}
+	return ray.NewRay(hit.Point, fuzzed)
+}
+
+// ========================= DielectricMaterial =========================
+
+// DielectricMaterial is transparent, with varying refractance index
+type DielectricMaterial struct {
+	refIdx      float64
+	attenuation *ray.Color
+}
+
+func NewDielectric(refIdx float64) *DielectricMaterial {
+	return &DielectricMaterial{
+		refIdx: refIdx,
+		// this can be used for translucent object
+		attenuation: &ray.Transparent,
+	}
+}
+
+func (d *DielectricMaterial) Color() *ray.Color {
+	return d.attenuation
+}
+
+// RefIdx returns the refractive index of the material
+func (d *DielectricMaterial) RefIdx() float64 {
+	return d.refIdx
+}
+
+// Schlick's approximation: https://en.wikipedia.org/wiki/Schlick%27s_approximation
+func (d *DielectricMaterial) schlick(cosine float64) float64 {
+	r0 := (1.0 - d.refIdx) / (1.0 + d.refIdx)
+	r0 = r0 * r0
+	return r0 + (1.0-r0)*math.Pow((1.0-cosine), 5)
+}
+
+func (d *DielectricMaterial) Bounce(r *ray.Ray, hit *Hit, src sampling.Source) *ray.Ray {
+	choice := src.Get1D()
+	unit := r.Direct.Normalize()
+
+	normalOutward, ratio := hit.Normal, 1.0/d.refIdx
+	cosine := -unit.Dot(hit.Normal)
+	leaving := cosine < 0
+	if leaving {
+		normalOutward, ratio, cosine = hit.Normal.Negate(), d.refIdx, -cosine
+	}
+
+	if refracted := unit.Refract(normalOutward, ratio); refracted != nil {
+		// Schlick's approximation takes the angle on the outer side
+		if leaving {
+			cosine = -refracted.Dot(normalOutward)
+		}
+		if choice > d.schlick(cosine) {
+			return ray.NewRay(hit.Point, refracted)
+		}
+	}
+	reflected := r.Direct.Reflect(hit.Normal)
+	return ray.NewRay(hit.Point, reflected)
+}
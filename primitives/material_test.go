@@ -0,0 +1,102 @@
+package primitives
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/Oaklight/Rayerson/ray"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// randSource draws uncorrelated values from a seeded generator
+type randSource struct {
+	*rand.Rand
+}
+
+func newRandSource(seed int64) randSource {
+	return randSource{rand.New(rand.NewSource(seed))}
+}
+
+func (s randSource) Get1D() float64 {
+	return s.Float64()
+}
+
+func (s randSource) Get2D() (float64, float64) {
+	return s.Float64(), s.Float64()
+}
+
+// reflectance computes Schlick's approximation for a ray crossing between
+// air and a medium of index n, cosine is taken on the air side
+func reflectance(n, cosine float64) float64 {
+	r0 := (1 - n) / (1 + n) * (1 - n) / (1 + n)
+	return r0 + (1-r0)*math.Pow(1-cosine, 5)
+}
+
+// TestDielectricSchlick bounces rays off a glass surface facing up, from
+// above and from below, and compares the fraction reflected with Schlick
+func TestDielectricSchlick(t *testing.T) {
+	const n, trials = 1.5, 100000
+	glass := NewDielectric(n)
+	up := &vec3.Vec3{Y: 1}
+	critical := math.Asin(1 / n)
+	cases := []struct {
+		name     string
+		incident float64
+		leaving  bool
+		want     float64
+	}{
+		{"entering, normal", 0, false, reflectance(n, 1)},
+		{"entering, 45 degrees", 45, false, reflectance(n, math.Cos(math.Pi/4))},
+		{"entering, 80 degrees", 80, false, reflectance(n, math.Cos(80*math.Pi/180))},
+		{"entering, 89 degrees", 89, false, reflectance(n, math.Cos(89*math.Pi/180))},
+		{"leaving, normal", 0, true, reflectance(n, 1)},
+		{"leaving, 30 degrees", 30, true, reflectance(n, math.Sqrt(1-n*n*0.25))},
+		{"leaving, 40 degrees", 40, true, reflectance(n, math.Sqrt(1-n*n*math.Pow(math.Sin(40*math.Pi/180), 2)))},
+		{"leaving, past the critical angle", critical*180/math.Pi + 1, true, 1},
+	}
+	for i, c := range cases {
+		theta := c.incident * math.Pi / 180
+		// the surface normal points up, out of the glass
+		dir := &vec3.Vec3{X: math.Sin(theta), Y: -math.Cos(theta)}
+		if c.leaving {
+			dir.Y = -dir.Y
+		}
+		// unnormalized like most rays of a render
+		r := ray.NewRay(&vec3.Vec3{}, dir.MulScalar(3))
+		hit := &Hit{Point: &vec3.Vec3{}, Normal: up, Materials: glass}
+
+		src := newRandSource(int64(i))
+		reflected := 0
+		for k := 0; k < trials; k++ {
+			out := glass.Bounce(r, hit, src)
+			if out == nil {
+				t.Fatalf("%s: ray absorbed", c.name)
+			}
+			// reflection stays on the incident side
+			if (out.Direct.Y > 0) != c.leaving {
+				reflected++
+				want := r.Direct.Reflect(up)
+				if d := out.Direct.Sub(want).Length(); d > 1e-9 {
+					t.Fatalf("%s: reflected along %v, want %v", c.name, *out.Direct, *want)
+				}
+			} else if sin := out.Direct.Normalize().X; math.Abs(sin-math.Sin(theta)*refractionRatio(n, c.leaving)) > 1e-9 {
+				t.Fatalf("%s: refracted with sine %g, want %g", c.name, sin, math.Sin(theta)*refractionRatio(n, c.leaving))
+			}
+		}
+
+		got := float64(reflected) / trials
+		sigma := math.Sqrt(c.want * (1 - c.want) / trials)
+		if math.Abs(got-c.want) > 4*sigma+1e-9 {
+			t.Errorf("%s: %.4f reflected, Schlick gives %.4f", c.name, got, c.want)
+		}
+	}
+}
+
+// refractionRatio is the Snell ratio of sines, transmitted over incident
+func refractionRatio(n float64, leaving bool) float64 {
+	if leaving {
+		return n
+	}
+	return 1 / n
+}
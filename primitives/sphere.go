@@ -2,8 +2,9 @@ package primitives
 
 import (
 	"math"
-	"ray"
-	vec3 "vector"
+
+	"github.com/Oaklight/Rayerson/ray"
+	vec3 "github.com/Oaklight/Rayerson/vector"
 )
 
 // Sphere has one centroid, and a radius
@@ -16,12 +17,20 @@ type Sphere struct {
 // NewSphere creates new Sphere obj
 func NewSphere(x, y, z, radius float64, m Materials) *Sphere {
 	return &Sphere{
-		Center:   &vec3.Vec3{x, y, z},
+		Center:   &vec3.Vec3{X: x, Y: y, Z: z},
 		Radius:   radius,
 		Material: m,
 	}
 }
 
+// Bounds returns the box around the sphere, a negative radius (used for
+// hollow glass) bounds the same sphere
+func (s *Sphere) Bounds() AABB {
+	radius := math.Abs(s.Radius)
+	r := &vec3.Vec3{X: radius, Y: radius, Z: radius}
+	return AABB{Min: *s.Center.Sub(r), Max: *s.Center.Add(r)}
+}
+
 // Hit a sphere could result in two hit spots, whichever first should win
 func (s *Sphere) Hit(r *ray.Ray, tMin, tMax float64) *Hit {
 	oc := r.Origin.Sub(s.Center)
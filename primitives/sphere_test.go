@@ -0,0 +1,92 @@
+package primitives
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Oaklight/Rayerson/ray"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+func newRay(ox, oy, oz, dx, dy, dz float64) *ray.Ray {
+	return ray.NewRay(&vec3.Vec3{X: ox, Y: oy, Z: oz}, &vec3.Vec3{X: dx, Y: dy, Z: dz})
+}
+
+func TestSphereHit(t *testing.T) {
+	s := NewSphere(0, 0, -5, 1, NewDiffuse(ray.NewColor(0.5, 0.5, 0.5)))
+	cases := []struct {
+		name   string
+		r      *ray.Ray
+		t      float64
+		normal vec3.Vec3
+	}{
+		{"front", newRay(0, 0, 0, 0, 0, -1), 4, vec3.Vec3{Z: 1}},
+		{"unnormalized direction", newRay(0, 0, 0, 0, 0, -2), 2, vec3.Vec3{Z: 1}},
+		// the normal keeps pointing out of the sphere
+		{"inside", newRay(0, 0, -5, 0, 0, -1), 1, vec3.Vec3{Z: -1}},
+		{"inside off center", newRay(0, 0.6, -5, 0, 0, 1), 0.8, vec3.Vec3{Y: 0.6, Z: 0.8}},
+		{"oblique", newRay(0, 0.6, 0, 0, 0, -1), 4.2, vec3.Vec3{Y: 0.6, Z: 0.8}},
+		{"nearly tangent", newRay(0, 1-1e-6, 0, 0, 0, -1), 5 - math.Sqrt(2e-6-1e-12), vec3.Vec3{Y: 1 - 1e-6, Z: math.Sqrt(2e-6 - 1e-12)}},
+	}
+	for _, c := range cases {
+		hit := s.Hit(c.r, 0.001, math.MaxFloat64)
+		if hit == nil {
+			t.Errorf("%s: missed", c.name)
+			continue
+		}
+		if math.Abs(hit.T-c.t) > 1e-6 {
+			t.Errorf("%s: t %g, want %g", c.name, hit.T, c.t)
+		}
+		if d := hit.Normal.Sub(&c.normal).Length(); d > 1e-6 {
+			t.Errorf("%s: normal %v, want %v", c.name, *hit.Normal, c.normal)
+		}
+		if d := hit.Point.Sub(c.r.PointAtScale(hit.T)).Length(); d > 1e-9 {
+			t.Errorf("%s: point %v is not on the ray", c.name, *hit.Point)
+		}
+		if hit.Materials != s.Material {
+			t.Errorf("%s: wrong material", c.name)
+		}
+	}
+}
+
+func TestSphereMiss(t *testing.T) {
+	s := NewSphere(0, 0, -5, 1, NewDiffuse(ray.NewColor(0.5, 0.5, 0.5)))
+	cases := []struct {
+		name       string
+		r          *ray.Ray
+		tMin, tMax float64
+	}{
+		{"behind", newRay(0, 0, 0, 0, 0, 1), 0.001, math.MaxFloat64},
+		{"beside", newRay(0, 2, 0, 0, 0, -1), 0.001, math.MaxFloat64},
+		// a grazing ray has a single root, counted as a miss
+		{"tangent", newRay(0, 1, 0, 0, 0, -1), 0.001, math.MaxFloat64},
+		{"beyond tMax", newRay(0, 0, 0, 0, 0, -1), 0.001, 3},
+		// leaving the surface, the hit at t = 0 is below tMin
+		{"on the surface going out", newRay(0, 0, -4, 0, 0, 1), 0.001, math.MaxFloat64},
+	}
+	for _, c := range cases {
+		if hit := s.Hit(c.r, c.tMin, c.tMax); hit != nil {
+			t.Errorf("%s: hit at t %g", c.name, hit.T)
+		}
+	}
+}
+
+func TestSphereBounds(t *testing.T) {
+	for _, radius := range []float64{2, -2} {
+		b := NewSphere(1, 2, 3, radius, nil).Bounds()
+		if b.Min != (vec3.Vec3{X: -1, Y: 0, Z: 1}) || b.Max != (vec3.Vec3{X: 3, Y: 4, Z: 5}) {
+			t.Errorf("radius %g: bounds %v", radius, b)
+		}
+	}
+}
+
+// BenchmarkSphereHit times the intersection at the heart of every render,
+// half the rays hitting
+func BenchmarkSphereHit(b *testing.B) {
+	s := NewSphere(0, 0, -5, 1, NewDiffuse(ray.NewColor(0.5, 0.5, 0.5)))
+	rays := []*ray.Ray{newRay(0, 0, 0, 0, 0, -1), newRay(0, 2, 0, 0, 0, -1)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Hit(rays[i&1], 0.001, math.MaxFloat64)
+	}
+}
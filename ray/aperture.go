@@ -0,0 +1,136 @@
+package ray
+
+import (
+	"errors"
+	"image"
+	"math"
+	"sort"
+
+	"github.com/Oaklight/Rayerson/sampling"
+)
+
+// Aperture is the shape of the lens opening, it gives the bokeh of out of
+// focus highlights
+type Aperture interface {
+	// Sample maps the next 2D sample of src to a point of the opening,
+	// within [-1, 1] along both axes
+	Sample(src sampling.Source) (x, y float64)
+}
+
+// ========================= CircularAperture =========================
+
+// CircularAperture is a perfectly round opening
+type CircularAperture struct{}
+
+// Sample draws a uniform point of the unit disc
+func (CircularAperture) Sample(src sampling.Source) (x, y float64) {
+	return sampling.ConcentricDisc(src.Get2D())
+}
+
+// ========================= PolygonalAperture =========================
+
+// PolygonalAperture is the regular polygon left by the straight blades of
+// a diaphragm, inscribed in the unit disc
+type PolygonalAperture struct {
+	Blades int
+	// Rotation turns the polygon counterclockwise, in radians
+	Rotation float64
+}
+
+// Sample draws a uniform point of the polygon, u1 picks one of the equal
+// triangles around the center then both are warped onto it
+func (p PolygonalAperture) Sample(src sampling.Source) (x, y float64) {
+	u1, u2 := src.Get2D()
+	n := float64(p.Blades)
+	i := math.Min(math.Floor(u1*n), n-1)
+	u1 = u1*n - i
+
+	step := 2 * math.Pi / n
+	a0, a1 := p.Rotation+i*step, p.Rotation+(i+1)*step
+	s := math.Sqrt(u1)
+	x = s * ((1-u2)*math.Cos(a0) + u2*math.Cos(a1))
+	y = s * ((1-u2)*math.Sin(a0) + u2*math.Sin(a1))
+	return x, y
+}
+
+// ========================= MaskAperture =========================
+
+// MaskAperture takes the opening from a grayscale image, white lets all
+// the light through and black none. The image is centered on the lens,
+// its longer side spanning the lens diameter.
+type MaskAperture struct {
+	width, height int
+	// rows is the cumulative weight of the rows, cols that of the pixels
+	// within each row
+	rows []float64
+	cols [][]float64
+	// scale maps pixel offsets from the center to [-1, 1]
+	scale float64
+}
+
+// NewMaskAperture creates an aperture from the luminance of img
+func NewMaskAperture(img image.Image) (*MaskAperture, error) {
+	b := img.Bounds()
+	m := &MaskAperture{
+		width:  b.Dx(),
+		height: b.Dy(),
+		rows:   make([]float64, b.Dy()),
+		cols:   make([][]float64, b.Dy()),
+		scale:  2 / float64(maxInt(b.Dx(), b.Dy())),
+	}
+	var total float64
+	for y := 0; y < m.height; y++ {
+		cdf := make([]float64, m.width)
+		var sum float64
+		for x := 0; x < m.width; x++ {
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			sum += (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(bl)) / 0xffff
+			cdf[x] = sum
+		}
+		m.cols[y] = cdf
+		total += sum
+		m.rows[y] = total
+	}
+	if total == 0 {
+		return nil, errors.New("aperture mask is black, no light goes through")
+	}
+	return m, nil
+}
+
+// Sample draws a point with a density proportional to the mask, u1 picks
+// the row and u2 the pixel within it
+func (m *MaskAperture) Sample(src sampling.Source) (x, y float64) {
+	u1, u2 := src.Get2D()
+	row, fy := pick(m.rows, u1)
+	col, fx := pick(m.cols[row], u2)
+	// image rows go down, the lens v axis up
+	x = (float64(col) + fx - float64(m.width)/2) * m.scale
+	y = (float64(m.height)/2 - float64(row) - fy) * m.scale
+	return x, y
+}
+
+// pick returns the index of the first entry of the cumulative weights cdf
+// above u times the total, with the position of u within that entry
+func pick(cdf []float64, u float64) (int, float64) {
+	total := cdf[len(cdf)-1]
+	target := u * total
+	i := sort.Search(len(cdf), func(i int) bool { return cdf[i] > target })
+	if i == len(cdf) {
+		i = len(cdf) - 1
+	}
+	lo := 0.0
+	if i > 0 {
+		lo = cdf[i-1]
+	}
+	if cdf[i] == lo {
+		return i, 0.5
+	}
+	return i, (target - lo) / (cdf[i] - lo)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,104 @@
+package ray
+
+import (
+	"math"
+
+	"github.com/Oaklight/Rayerson/sampling"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// Camera maps image positions to primary rays
+type Camera interface {
+	// GetRay returns the ray at NDC (u, v), (0, 0) being the bottom left
+	// corner of the image and (1, 1) the top right one. Cameras draw the
+	// lens position, if they need one, from the next 2D sample of src.
+	// A nil ray means (u, v) sees nothing, like the corners of a circular
+	// fisheye.
+	GetRay(u, v float64, src sampling.Source) *Ray
+}
+
+// frame is the orthonormal basis of a camera at origin, looking along -w
+// with v up
+type frame struct {
+	origin  *vec3.Vec3
+	u, v, w *vec3.Vec3
+}
+
+func newFrame(pos, lookAt, up vec3.Vec3) frame {
+	w := pos.Sub(&lookAt).Normalize()
+	u := up.Cross(w).Normalize()
+	v := w.Cross(u) // normalized already
+	return frame{origin: &pos, u: u, v: v, w: w}
+}
+
+// toWorld returns the direction (x, y, z) of the camera basis in world
+// space, z looking backward
+func (f *frame) toWorld(x, y, z float64) *vec3.Vec3 {
+	return vec3.Add(f.u.MulScalar(x), f.v.MulScalar(y), f.w.MulScalar(z))
+}
+
+// ========================= PerspectiveCamera =========================
+
+// PerspectiveCamera is a pinhole camera, or a thin lens one when its
+// aperture is not zero
+type PerspectiveCamera struct {
+	frame
+	lowerLeft, horizontal, vertical *vec3.Vec3
+	lensRadius                      float64
+	aperture                        Aperture
+}
+
+// NewPerspectiveCamera creates a thin lens camera
+// ** fov is the vertical field of view in degrees
+// ** lookAt is a point
+// ** a non-positive focusDist focuses on lookAt
+func NewPerspectiveCamera(fov, aspect, aperture, focusDist float64, pos, lookAt, up vec3.Vec3) *PerspectiveCamera {
+	theta := fov * math.Pi / 180
+	halfHeight := math.Tan(theta / 2)
+	halfWidth := aspect * halfHeight
+	f := newFrame(pos, lookAt, up)
+	if focusDist <= 0 {
+		focusDist = (&pos).Sub(&lookAt).Length()
+	}
+	x := f.u.MulScalar(halfWidth * focusDist)
+	y := f.v.MulScalar(halfHeight * focusDist)
+	return &PerspectiveCamera{
+		frame:      f,
+		lowerLeft:  (&pos).Sub(x, y, f.w.MulScalar(focusDist)),
+		horizontal: x.MulScalar(2),
+		vertical:   y.MulScalar(2),
+		lensRadius: aperture / 2,
+		aperture:   CircularAperture{},
+	}
+}
+
+// SetAperture changes the shape of the lens opening, scaled to the lens
+// diameter, it is round by default
+func (c *PerspectiveCamera) SetAperture(a Aperture) {
+	c.aperture = a
+}
+
+// Shift moves the image window within the focal plane by dx image widths
+// and dy image heights, the off-axis projection of parallel stereo rigs
+func (c *PerspectiveCamera) Shift(dx, dy float64) {
+	c.lowerLeft = vec3.Add(c.lowerLeft, c.horizontal.MulScalar(dx), c.vertical.MulScalar(dy))
+}
+
+// GetRay returns the ray at shifted NDC (u,v), the lens position is drawn
+// from the next 2D sample of src
+func (c *PerspectiveCamera) GetRay(u, v float64, src sampling.Source) *Ray {
+	lx, ly := c.aperture.Sample(src)
+	offset := c.toWorld(lx*c.lensRadius, ly*c.lensRadius, 0)
+
+	return NewRay(
+		c.origin.Add(offset),
+		vec3.Add(
+			c.lowerLeft,
+			c.horizontal.MulScalar(u),
+			c.vertical.MulScalar(v),
+		).Sub(
+			c.origin,
+			offset,
+		),
+	)
+}
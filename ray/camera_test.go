@@ -0,0 +1,116 @@
+package ray
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Oaklight/Rayerson/sampling"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+func closeTo(a, b *vec3.Vec3, tol float64) bool {
+	return a.Sub(b).Length() <= tol
+}
+
+// TestPerspectiveCorners looks down -z from the origin with a 90 degrees
+// vertical field of view, the image plane at distance 1 spans [-2, 2] by
+// [-1, 1]
+func TestPerspectiveCorners(t *testing.T) {
+	cam := NewPerspectiveCamera(90, 2, 0, 0, vec3.Vec3{}, vec3.Vec3{Z: -1}, vec3.Vec3{Y: 1})
+	src := sampling.NewIndependent(1)
+	cases := []struct {
+		u, v float64
+		want vec3.Vec3
+	}{
+		{0, 0, vec3.Vec3{X: -2, Y: -1, Z: -1}},
+		{1, 0, vec3.Vec3{X: 2, Y: -1, Z: -1}},
+		{0, 1, vec3.Vec3{X: -2, Y: 1, Z: -1}},
+		{1, 1, vec3.Vec3{X: 2, Y: 1, Z: -1}},
+		{0.5, 0.5, vec3.Vec3{Z: -1}},
+	}
+	for _, c := range cases {
+		r := cam.GetRay(c.u, c.v, src)
+		if !closeTo(r.Origin, &vec3.Vec3{}, 1e-12) {
+			t.Errorf("(%g, %g): pinhole ray from %v", c.u, c.v, *r.Origin)
+		}
+		if !closeTo(r.Direct.Normalize(), c.want.Normalize(), 1e-12) {
+			t.Errorf("(%g, %g): direction %v, want along %v", c.u, c.v, *r.Direct, c.want)
+		}
+	}
+
+	// opposite corners are symmetric about the view axis
+	a, b := cam.GetRay(0, 0, src).Direct, cam.GetRay(1, 1, src).Direct
+	if !closeTo(a.Add(b).Normalize(), &vec3.Vec3{Z: -1}, 1e-12) {
+		t.Errorf("corners %v and %v are not symmetric", *a, *b)
+	}
+	// the vertical edges are 45 degrees off axis
+	top := cam.GetRay(0.5, 1, src).Direct.Normalize()
+	if angle := math.Acos(-top.Z) * 180 / math.Pi; math.Abs(angle-45) > 1e-9 {
+		t.Errorf("top edge at %g degrees, want 45", angle)
+	}
+}
+
+// TestPerspectiveFocus checks rays through any point of the lens meet on
+// the focal plane
+func TestPerspectiveFocus(t *testing.T) {
+	pos, lookAt := vec3.Vec3{X: 1, Y: 2, Z: 3}, vec3.Vec3{X: -2, Y: 0, Z: -4}
+	cam := NewPerspectiveCamera(40, 1.5, 0.5, 6, pos, lookAt, vec3.Vec3{Y: 1})
+	src := sampling.NewIndependent(2)
+	for _, uv := range [][2]float64{{0, 0}, {1, 1}, {0.3, 0.8}} {
+		var focus *vec3.Vec3
+		for i := 0; i < 16; i++ {
+			r := cam.GetRay(uv[0], uv[1], src)
+			if d := r.Origin.Sub(&pos).Length(); d > 0.25+1e-12 {
+				t.Fatalf("lens sample %g away from the center, radius 0.25", d)
+			}
+			// directions reach the focal plane at t = 1
+			p := r.PointAtScale(1)
+			if focus == nil {
+				focus = p
+			} else if !closeTo(p, focus, 1e-9) {
+				t.Errorf("(%g, %g): rays meet at %v and %v", uv[0], uv[1], *focus, *p)
+			}
+		}
+	}
+	// the image center is focused 6 units along the view direction
+	view := lookAt.Sub(&pos).Normalize()
+	center := cam.GetRay(0.5, 0.5, src).PointAtScale(1)
+	if d := center.Sub(&pos).Dot(view); math.Abs(d-6) > 1e-9 {
+		t.Errorf("focal plane at %g, want 6", d)
+	}
+}
+
+func TestOrthographicCorners(t *testing.T) {
+	cam := NewOrthographicCamera(2, 2, vec3.Vec3{Z: 5}, vec3.Vec3{}, vec3.Vec3{Y: 1})
+	cases := []struct {
+		u, v float64
+		want vec3.Vec3
+	}{
+		{0, 0, vec3.Vec3{X: -2, Y: -1, Z: 5}},
+		{1, 1, vec3.Vec3{X: 2, Y: 1, Z: 5}},
+		{0.5, 0.5, vec3.Vec3{Z: 5}},
+	}
+	for _, c := range cases {
+		r := cam.GetRay(c.u, c.v, nil)
+		if !closeTo(r.Origin, &c.want, 1e-12) {
+			t.Errorf("(%g, %g): origin %v, want %v", c.u, c.v, *r.Origin, c.want)
+		}
+		if !closeTo(r.Direct, &vec3.Vec3{Z: -1}, 1e-12) {
+			t.Errorf("(%g, %g): direction %v, want (0, 0, -1)", c.u, c.v, *r.Direct)
+		}
+	}
+}
+
+func TestFisheyeCorners(t *testing.T) {
+	cam := NewFisheyeCamera(180, 2, Equidistant, vec3.Vec3{}, vec3.Vec3{Z: -1}, vec3.Vec3{Y: 1})
+	// corners lie outside the image circle
+	for _, uv := range [][2]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		if r := cam.GetRay(uv[0], uv[1], nil); r != nil {
+			t.Errorf("(%g, %g): got %v, want no ray", uv[0], uv[1], *r.Direct)
+		}
+	}
+	// the rim of the circle sees 90 degrees off axis
+	if r := cam.GetRay(0.5, 1, nil); r == nil || math.Abs(r.Direct.Normalize().Z) > 1e-9 {
+		t.Errorf("top of the circle: got %v, want a ray perpendicular to the axis", r)
+	}
+}
@@ -3,7 +3,8 @@ package ray
 import (
 	"image/color"
 	"math"
-	"vector"
+
+	"github.com/Oaklight/Rayerson/vector"
 )
 
 // Color is a 64-bit color array
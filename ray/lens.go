@@ -0,0 +1,24 @@
+package ray
+
+import "math"
+
+// FullFrameHeight is the height in millimeters of the 35 mm film frame
+// focal lengths are given for
+const FullFrameHeight = 24.0
+
+// FOVFromFocalLength returns the vertical field of view in degrees of a
+// lens of focalLength millimeters on a full frame sensor
+func FOVFromFocalLength(focalLength float64) float64 {
+	return 2 * math.Atan(FullFrameHeight/(2*focalLength)) * 180 / math.Pi
+}
+
+// FocalLengthFromFOV is the inverse of FOVFromFocalLength
+func FocalLengthFromFOV(fov float64) float64 {
+	return FullFrameHeight / (2 * math.Tan(fov*math.Pi/360))
+}
+
+// ApertureFromFNumber returns the lens diameter in scene units, taken as
+// meters, of a lens of focalLength millimeters opened at fNumber
+func ApertureFromFNumber(focalLength, fNumber float64) float64 {
+	return focalLength / fNumber / 1000
+}
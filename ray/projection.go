@@ -0,0 +1,121 @@
+package ray
+
+import (
+	"math"
+
+	"github.com/Oaklight/Rayerson/sampling"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// ========================= OrthographicCamera =========================
+
+// OrthographicCamera casts parallel rays from a rectangle facing lookAt,
+// objects keep their size whatever their distance
+type OrthographicCamera struct {
+	frame
+	halfWidth, halfHeight float64
+}
+
+// NewOrthographicCamera creates an orthographic camera seeing height world
+// units vertically
+func NewOrthographicCamera(height, aspect float64, pos, lookAt, up vec3.Vec3) *OrthographicCamera {
+	return &OrthographicCamera{
+		frame:      newFrame(pos, lookAt, up),
+		halfWidth:  aspect * height / 2,
+		halfHeight: height / 2,
+	}
+}
+
+// GetRay returns the ray at NDC (u, v), all rays share the view direction
+func (c *OrthographicCamera) GetRay(u, v float64, src sampling.Source) *Ray {
+	x := (2*u - 1) * c.halfWidth
+	y := (2*v - 1) * c.halfHeight
+	return NewRay(c.origin.Add(c.toWorld(x, y, 0)), c.w.Negate())
+}
+
+// ========================= FisheyeCamera =========================
+
+// FisheyeMapping tells how a fisheye lens maps the angle to the optical
+// axis onto the distance to the image center
+type FisheyeMapping int
+
+const (
+	// Equidistant keeps the distance proportional to the angle
+	Equidistant FisheyeMapping = iota
+	// Equisolid keeps areas proportional to solid angles
+	Equisolid
+)
+
+// FisheyeCamera is a circular fisheye, the image circle touches the
+// shorter side of the image and the corners outside of it see nothing
+type FisheyeCamera struct {
+	frame
+	mapping FisheyeMapping
+	// thetaMax is the angle to the axis seen at the rim of the circle
+	thetaMax float64
+	// scaleX and scaleY map NDC to the unit image circle
+	scaleX, scaleY float64
+}
+
+// NewFisheyeCamera creates a fisheye camera covering fov degrees across the
+// image circle, up to 360
+func NewFisheyeCamera(fov, aspect float64, mapping FisheyeMapping, pos, lookAt, up vec3.Vec3) *FisheyeCamera {
+	c := &FisheyeCamera{
+		frame:    newFrame(pos, lookAt, up),
+		mapping:  mapping,
+		thetaMax: fov * math.Pi / 360,
+		scaleX:   1,
+		scaleY:   1,
+	}
+	if aspect > 1 {
+		c.scaleX = aspect
+	} else {
+		c.scaleY = 1 / aspect
+	}
+	return c
+}
+
+// GetRay returns the ray at NDC (u, v), or nil outside the image circle
+func (c *FisheyeCamera) GetRay(u, v float64, src sampling.Source) *Ray {
+	x := (2*u - 1) * c.scaleX
+	y := (2*v - 1) * c.scaleY
+	r := math.Hypot(x, y)
+	if r > 1 {
+		return nil
+	}
+
+	var theta float64
+	switch c.mapping {
+	case Equisolid:
+		theta = 2 * math.Asin(r*math.Sin(c.thetaMax/2))
+	default:
+		theta = r * c.thetaMax
+	}
+	phi := math.Atan2(y, x)
+	sinTheta := math.Sin(theta)
+	direct := c.toWorld(sinTheta*math.Cos(phi), sinTheta*math.Sin(phi), -math.Cos(theta))
+	return NewRay(c.origin, direct)
+}
+
+// ========================= EquirectCamera =========================
+
+// EquirectCamera sees every direction around it, longitude along the
+// image width and latitude along its height, lookAt is at the center
+type EquirectCamera struct {
+	frame
+}
+
+// NewEquirectCamera creates a 360 degrees camera, the image should be twice
+// as wide as high
+func NewEquirectCamera(pos, lookAt, up vec3.Vec3) *EquirectCamera {
+	return &EquirectCamera{newFrame(pos, lookAt, up)}
+}
+
+// GetRay returns the ray at NDC (u, v)
+func (c *EquirectCamera) GetRay(u, v float64, src sampling.Source) *Ray {
+	phi := (2*u - 1) * math.Pi
+	theta := (v - 0.5) * math.Pi
+	cosTheta := math.Cos(theta)
+	direct := c.toWorld(cosTheta*math.Sin(phi), math.Sin(theta), -cosTheta*math.Cos(phi))
+	return NewRay(c.origin, direct)
+}
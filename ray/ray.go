@@ -1,6 +1,6 @@
 package ray
 
-import "vector"
+import "github.com/Oaklight/Rayerson/vector"
 
 // Ray comprises of an origin, and a direction
 type Ray struct {
@@ -0,0 +1,225 @@
+package rayerson
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+
+	pm "github.com/Oaklight/Rayerson/primitives"
+	"github.com/Oaklight/Rayerson/render"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// Options are the image and sampling settings of a Renderer
+type Options struct {
+	Width, Height int
+	// Samples per pixel, the cap when sampling adaptively
+	Samples int
+	// MaxDepth is the maximum number of bounces per path
+	MaxDepth int
+	Seed     int64
+	// Threads is the number of render workers, 0 uses every CPU
+	Threads int
+	// Sampler is the pixel sampling pattern, one of sampling.Names
+	Sampler string
+	// Filter is the reconstruction filter, one of render.FilterNames, its
+	// radius in pixels is FilterRadius or the filter's default when 0
+	Filter       string
+	FilterRadius float64
+	// Pixels take MinSamples, then stop once their relative noise is below
+	// NoiseThreshold, 0 always takes Samples
+	MinSamples     int
+	NoiseThreshold float64
+	// Denoise is one of render.DenoiseNames, the atrous denoiser runs
+	// DenoiseIterations passes
+	Denoise           string
+	DenoiseIterations int
+	// Environment lights the rays leaving the scene, nil for the sky
+	Environment render.Environment
+}
+
+// DefaultOptions returns the settings of the render command without flags
+func DefaultOptions() Options {
+	o := render.DefaultOptions()
+	return Options{
+		Width:             o.Width,
+		Height:            o.Height,
+		Samples:           o.Samples,
+		MaxDepth:          o.MaxDepth,
+		Seed:              o.Seed,
+		Threads:           o.Threads,
+		Sampler:           o.Pattern,
+		Filter:            o.Filter,
+		FilterRadius:      o.FilterRadius,
+		MinSamples:        o.MinSamples,
+		NoiseThreshold:    o.NoiseThreshold,
+		Denoise:           o.Denoise,
+		DenoiseIterations: o.DenoiseIterations,
+	}
+}
+
+// Camera places the eye and describes its lens
+type Camera struct {
+	// Projection is one of render.ProjectionNames
+	Projection      string
+	Pos, LookAt, Up vec3.Vec3
+	// FOV is the vertical field of view in degrees, of the image circle for
+	// fisheyes
+	FOV float64
+	// OrthoHeight is the height seen by the orthographic camera, 0 matches
+	// FOV at the LookAt distance
+	OrthoHeight float64
+	// Aperture is the lens diameter, FocusDist the distance to the focal
+	// plane or 0 to focus on LookAt
+	Aperture, FocusDist float64
+	// FocalLength in mm on a full frame sensor replaces FOV, and FNumber
+	// replaces Aperture, when not 0
+	FocalLength, FNumber float64
+	// Autofocus focuses on the surface seen through pixel "x,y" from the
+	// top left corner, or "center", replacing FocusDist
+	Autofocus string
+	// ApertureBlades gives a polygonal bokeh rotated by ApertureRotation
+	// degrees, 0 a round one
+	ApertureBlades   int
+	ApertureRotation float64
+}
+
+// DefaultCamera returns the camera of the render command without flags
+func DefaultCamera() Camera {
+	o := render.DefaultOptions()
+	return Camera{
+		Projection:       o.Projection,
+		Pos:              o.Pos,
+		LookAt:           o.LookAt,
+		Up:               o.Up,
+		FOV:              o.FOV,
+		OrthoHeight:      o.OrthoHeight,
+		Aperture:         o.Aperture,
+		FocusDist:        o.FocusDist,
+		FocalLength:      o.FocalLength,
+		FNumber:          o.FNumber,
+		Autofocus:        o.Autofocus,
+		ApertureBlades:   o.ApertureBlades,
+		ApertureRotation: o.ApertureRotation,
+	}
+}
+
+// Scene holds the objects a Renderer draws
+type Scene struct {
+	world *pm.World
+}
+
+// NewScene returns a scene of the given objects, like the spheres of
+// primitives.NewSphere
+func NewScene(objects ...pm.Hitable) *Scene {
+	world := pm.World(objects)
+	return &Scene{&world}
+}
+
+// LoadScene reads a scene file, CSV or JSON after its extension
+func LoadScene(path string) (*Scene, error) {
+	world, err := render.LoadScene(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Scene{world}, nil
+}
+
+// ReadScene reads a scene in format, one of render.SceneFormatNames
+func ReadScene(r io.Reader, format string) (*Scene, error) {
+	f, err := render.SceneFormatByName(format)
+	if err != nil {
+		return nil, err
+	}
+	world, err := f.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Scene{world}, nil
+}
+
+// Add appends objects to the scene, not while it is being rendered
+func (s *Scene) Add(objects ...pm.Hitable) {
+	s.world.Add(objects...)
+}
+
+// World returns the objects of the scene
+func (s *Scene) World() *pm.World {
+	return s.world
+}
+
+// Renderer renders one scene through one camera. Render may be called
+// from several goroutines, every call renders on its own.
+type Renderer struct {
+	opts  *render.Options
+	scene *Scene
+	env   render.Environment
+}
+
+// New checks the settings and returns a renderer of scene, errors name
+// the settings after the flags of the render command. An autofocus is
+// resolved here, against the objects the scene has now.
+func New(opts Options, scene *Scene, cam Camera) (*Renderer, error) {
+	o := render.DefaultOptions()
+	o.Width, o.Height = opts.Width, opts.Height
+	o.Samples, o.MaxDepth = opts.Samples, opts.MaxDepth
+	o.Seed, o.Threads = opts.Seed, opts.Threads
+	o.Pattern, o.Filter, o.FilterRadius = opts.Sampler, opts.Filter, opts.FilterRadius
+	o.MinSamples, o.NoiseThreshold = opts.MinSamples, opts.NoiseThreshold
+	o.Denoise, o.DenoiseIterations = opts.Denoise, opts.DenoiseIterations
+
+	o.Projection = cam.Projection
+	o.Pos, o.LookAt, o.Up = cam.Pos, cam.LookAt, cam.Up
+	o.FOV, o.OrthoHeight = cam.FOV, cam.OrthoHeight
+	o.Aperture, o.FocusDist = cam.Aperture, cam.FocusDist
+	o.FocalLength, o.FNumber = cam.FocalLength, cam.FNumber
+	o.Autofocus = cam.Autofocus
+	o.ApertureBlades, o.ApertureRotation = cam.ApertureBlades, cam.ApertureRotation
+
+	if scene == nil {
+		return nil, errors.New("no scene to render")
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	if o.Autofocus != "" {
+		if err := o.FocusOn(scene.world); err != nil {
+			return nil, err
+		}
+	}
+	return &Renderer{o, scene, opts.Environment}, nil
+}
+
+// Render renders the scene. When ctx is cancelled the pixels done so far
+// are returned with a *render.IncompleteError.
+func (r *Renderer) Render(ctx context.Context) (image.Image, error) {
+	s, err := r.opts.NewSampler()
+	if err != nil {
+		return nil, err
+	}
+	s.SetWorldObj(r.scene.world)
+	if r.env != nil {
+		s.SetEnvironment(r.env)
+	}
+	if _, err := s.Render(ctx); err != nil {
+		return s.ImgOut, err
+	}
+	if r.opts.Denoise != "none" {
+		if err := s.Denoise(r.opts.DenoiseOptions()); err != nil {
+			return nil, err
+		}
+	}
+	return s.ImgOut, nil
+}
+
+// RenderTo renders the scene and writes it to w as a PNG image, nothing
+// is written when the render is cancelled
+func (r *Renderer) RenderTo(ctx context.Context, w io.Writer) error {
+	img, err := r.Render(ctx)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,120 @@
+package rayerson_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Oaklight/Rayerson"
+	pm "github.com/Oaklight/Rayerson/primitives"
+	"github.com/Oaklight/Rayerson/ray"
+	"github.com/Oaklight/Rayerson/render"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+func Example() {
+	scene := rayerson.NewScene(
+		pm.NewSphere(0, -1000, 0, 1000, pm.NewDiffuse(ray.NewColor(0.5, 0.5, 0.5))),
+		pm.NewSphere(0, 1, 0, 1, pm.NewDielectric(1.5)),
+	)
+	opts := rayerson.DefaultOptions()
+	opts.Width, opts.Height, opts.Samples = 320, 180, 64
+	cam := rayerson.DefaultCamera()
+	cam.Pos, cam.LookAt = vec3.Vec3{X: 0, Y: 2, Z: 6}, vec3.Vec3{X: 0, Y: 1, Z: 0}
+
+	r, err := rayerson.New(opts, scene, cam)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out, err := os.Create("out.png")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer out.Close()
+	if err := r.RenderTo(context.Background(), out); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func smallOptions() rayerson.Options {
+	opts := rayerson.DefaultOptions()
+	opts.Width, opts.Height, opts.Samples = 40, 20, 4
+	opts.NoiseThreshold = 0
+	return opts
+}
+
+// TestRenderMatchesSampler checks the renderer draws what the render
+// command does with the same settings
+func TestRenderMatchesSampler(t *testing.T) {
+	scene, err := rayerson.LoadScene("test/sceneSimple.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := rayerson.New(smallOptions(), scene, rayerson.DefaultCamera())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.Render(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := render.DefaultOptions()
+	o.Width, o.Height, o.Samples, o.NoiseThreshold = 40, 20, 4, 0
+	s, err := o.NewSampler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetWorldObj(scene.World())
+	want, err := s.Render(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.(*image.RGBA64).Pix, want.Pix) {
+		t.Error("the renderer and the sampler drew different images")
+	}
+}
+
+func TestRenderConcurrently(t *testing.T) {
+	scene, err := rayerson.ReadScene(strings.NewReader("0,0,-1,0.5,Diffuse,0.8,0.3,0.3\n"), "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := rayerson.New(smallOptions(), scene, rayerson.DefaultCamera())
+	if err != nil {
+		t.Fatal(err)
+	}
+	outs := make([]bytes.Buffer, 4)
+	var wg sync.WaitGroup
+	for i := range outs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := r.RenderTo(context.Background(), &outs[i]); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i := range outs {
+		if !bytes.Equal(outs[i].Bytes(), outs[0].Bytes()) {
+			t.Errorf("render %d differs from the first", i)
+		}
+	}
+}
+
+func TestNewRejectsBadSettings(t *testing.T) {
+	opts := smallOptions()
+	opts.Width = 0
+	if _, err := rayerson.New(opts, rayerson.NewScene(), rayerson.DefaultCamera()); err == nil {
+		t.Error("expect an error for a zero width")
+	}
+	if _, err := rayerson.New(smallOptions(), nil, rayerson.DefaultCamera()); err == nil {
+		t.Error("expect an error without a scene")
+	}
+}
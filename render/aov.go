@@ -0,0 +1,298 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Oaklight/Rayerson/exr"
+	pm "github.com/Oaklight/Rayerson/primitives"
+	"github.com/Oaklight/Rayerson/ray"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// AOVNames lists the arbitrary output variables a render can keep beside
+// the image, all of them about the first surface seen by camera rays
+var AOVNames = []string{"depth", "normal", "albedo", "material", "object"}
+
+// aovBuffer accumulates the first hit of every camera sample, depth,
+// normal and albedo are averaged over the samples hitting something, the
+// ids are those of the first sample of the pixel, -1 when it missed
+type aovBuffer struct {
+	bounds   image.Rectangle
+	hits     []int
+	depth    []float64
+	normal   []vec3.Vec3
+	albedo   []ray.Color
+	material []int
+	object   []int
+}
+
+func newAOVBuffer(bounds image.Rectangle) *aovBuffer {
+	n := bounds.Dx() * bounds.Dy()
+	b := &aovBuffer{
+		bounds:   bounds,
+		hits:     make([]int, n),
+		depth:    make([]float64, n),
+		normal:   make([]vec3.Vec3, n),
+		albedo:   make([]ray.Color, n),
+		material: make([]int, n),
+		object:   make([]int, n),
+	}
+	for i := range b.material {
+		b.material[i], b.object[i] = -1, -1
+	}
+	return b
+}
+
+// record adds sample index of pixel (x, y), whose camera ray r first hits
+// object of the world, or nothing when hit is nil. Only the tile owning
+// the pixel records it, so no lock is needed.
+func (b *aovBuffer) record(x, y, index int, r *ray.Ray, hit *pm.Hit, object, material int) {
+	if hit == nil {
+		return
+	}
+	i := (y-b.bounds.Min.Y)*b.bounds.Dx() + (x - b.bounds.Min.X)
+	b.hits[i]++
+	b.depth[i] += hit.T * r.Direct.Length()
+	b.normal[i] = *b.normal[i].Add(hit.Normal.Normalize())
+	b.albedo[i] = *b.albedo[i].Add(hit.Color())
+	if index == 0 {
+		b.object[i], b.material[i] = object, material
+	}
+}
+
+// Layer is a float image of one or more interleaved channels, rows from
+// the top
+type Layer struct {
+	Name          string
+	Channels      []string
+	Width, Height int
+	Pix           []float64
+}
+
+func newLayer(name string, width, height int, channels ...string) *Layer {
+	return &Layer{
+		Name:     name,
+		Channels: channels,
+		Width:    width,
+		Height:   height,
+		Pix:      make([]float64, width*height*len(channels)),
+	}
+}
+
+// At returns the channels of pixel (x, y), counted from the top left
+func (l *Layer) At(x, y int) []float64 {
+	i := (y*l.Width + x) * len(l.Channels)
+	return l.Pix[i : i+len(l.Channels)]
+}
+
+// EnableAOVs makes the next renders keep the named buffers of AOVNames
+func (s *Sampler) EnableAOVs(names ...string) error {
+	for _, name := range names {
+		if !contains(AOVNames, name) {
+			return fmt.Errorf("unknown AOV %q, expect some of %s", name, strings.Join(AOVNames, ", "))
+		}
+	}
+	s.aovNames = names
+	s.aov = newAOVBuffer(s.film.bounds)
+	return nil
+}
+
+// materialIndex numbers the materials of world by first appearance
+func materialIndex(world *pm.World) map[pm.Materials]int {
+	index := map[pm.Materials]int{}
+	for _, each := range *world {
+		if s, ok := each.(*pm.Sphere); ok {
+			if _, seen := index[s.Material]; !seen {
+				index[s.Material] = len(index)
+			}
+		}
+	}
+	return index
+}
+
+// recordAOVs traces the camera ray of sample index of pixel (x, y) to its
+// first hit
+func (s *Sampler) recordAOVs(x, y, index int, r *ray.Ray) {
+	hit, object := s.world.HitIndex(r, s.tMin, s.tMax)
+	material := -1
+	if hit != nil {
+		if id, ok := s.materials[hit.Materials]; ok {
+			material = id
+		}
+	}
+	s.aov.record(x, y, index, r, hit, object, material)
+}
+
+// Beauty returns the rendered image as linear colors
+func (s *Sampler) Beauty() *Layer {
+	l := newLayer("beauty", s.width, s.height, "R", "G", "B")
+	s.filmLock.Lock()
+	defer s.filmLock.Unlock()
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; x++ {
+			c := s.film.color(x, y)
+			copy(l.At(x, s.height-1-y), []float64{c.R, c.G, c.B})
+		}
+	}
+	return l
+}
+
+// AOVs returns the enabled buffers in the order they were enabled, depth
+// is +Inf and ids are -1 where camera rays hit nothing
+func (s *Sampler) AOVs() []*Layer {
+	if s.aov == nil {
+		return nil
+	}
+	return s.layers(s.aovNames)
+}
+
+// layers builds the named buffers, enabled or not
+func (s *Sampler) layers(names []string) []*Layer {
+	b := s.aov
+	var layers []*Layer
+	for _, name := range names {
+		var l *Layer
+		switch name {
+		case "depth":
+			l = newLayer(name, s.width, s.height, "Z")
+		case "normal":
+			l = newLayer(name, s.width, s.height, "X", "Y", "Z")
+		case "albedo":
+			l = newLayer(name, s.width, s.height, "R", "G", "B")
+		case "material", "object":
+			l = newLayer(name, s.width, s.height, "id")
+		}
+		for y := 0; y < s.height; y++ {
+			for x := 0; x < s.width; x++ {
+				i := y*s.width + x
+				px := l.At(x, s.height-1-y)
+				hits := float64(b.hits[i])
+				switch name {
+				case "depth":
+					px[0] = math.Inf(1)
+					if hits > 0 {
+						px[0] = b.depth[i] / hits
+					}
+				case "normal":
+					if n := b.normal[i]; hits > 0 && n.Length() > 0 {
+						n := n.Normalize()
+						copy(px, []float64{n.X, n.Y, n.Z})
+					}
+				case "albedo":
+					if hits > 0 {
+						a := b.albedo[i].DivScalar(hits)
+						copy(px, []float64{a.R, a.G, a.B})
+					}
+				case "material":
+					px[0] = float64(b.material[i])
+				case "object":
+					px[0] = float64(b.object[i])
+				}
+			}
+		}
+		layers = append(layers, l)
+	}
+	return layers
+}
+
+// Image maps the layer to a viewable image: depth to gray, near being
+// white, normals and albedo to colors and ids to distinct colors, pixels
+// seeing nothing are black
+func (l *Layer) Image() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, l.Width, l.Height))
+	far := 0.0
+	if l.Name == "depth" {
+		for _, d := range l.Pix {
+			if !math.IsInf(d, 1) {
+				far = math.Max(far, d)
+			}
+		}
+	}
+	channel := func(v float64) uint8 {
+		return uint8(math.Max(0, math.Min(255, v*255)))
+	}
+	for y := 0; y < l.Height; y++ {
+		for x := 0; x < l.Width; x++ {
+			px := l.At(x, y)
+			var c color.RGBA
+			switch {
+			case l.Name == "depth":
+				if !math.IsInf(px[0], 1) && far > 0 {
+					v := channel(1 - px[0]/far)
+					c = color.RGBA{v, v, v, 255}
+				}
+			case l.Name == "normal":
+				if px[0] != 0 || px[1] != 0 || px[2] != 0 {
+					c = color.RGBA{channel(px[0]/2 + 0.5), channel(px[1]/2 + 0.5), channel(px[2]/2 + 0.5), 255}
+				}
+			case len(l.Channels) == 1:
+				if px[0] >= 0 {
+					c = idColor(int(px[0]))
+				}
+			default:
+				// gamma 2 like the rendered image
+				c = color.RGBA{channel(math.Sqrt(px[0])), channel(math.Sqrt(px[1])), channel(math.Sqrt(px[2])), 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// idColor picks a stable color for an id, hues of neighbour ids are far
+// apart thanks to the golden ratio
+func idColor(id int) color.RGBA {
+	hue := math.Mod(float64(id)*0.618033988749895, 1) * 6
+	f := hue - math.Floor(hue)
+	// saturation 0.7 and value 0.95
+	v, p := 0.95, 0.95*0.3
+	q, t := 0.95*(1-0.7*f), 0.95*(1-0.7*(1-f))
+	rgb := [6][3]float64{{v, t, p}, {q, v, p}, {p, v, t}, {p, q, v}, {t, p, v}, {v, p, q}}[int(hue)]
+	return color.RGBA{uint8(rgb[0] * 255), uint8(rgb[1] * 255), uint8(rgb[2] * 255), 255}
+}
+
+// AOVPath returns where the layer name of an image written to filePath
+// goes, like out-depth.png for out.png
+func AOVPath(filePath, name string) string {
+	ext := filepath.Ext(filePath)
+	return strings.TrimSuffix(filePath, ext) + "-" + name + ext
+}
+
+// SaveEXR writes the layers into one multi-layer OpenEXR file, the
+// channels of the beauty layer keep their bare names, the others are
+// prefixed by their layer
+func SaveEXR(filePath string, layers []*Layer) error {
+	if len(layers) == 0 {
+		return fmt.Errorf("%s: no layer to write", filePath)
+	}
+	width, height := layers[0].Width, layers[0].Height
+	var channels []exr.Channel
+	for _, l := range layers {
+		for c, name := range l.Channels {
+			if l.Name != "beauty" {
+				name = l.Name + "." + name
+			}
+			data := make([]float32, width*height)
+			for i := range data {
+				data[i] = float32(l.Pix[i*len(l.Channels)+c])
+			}
+			channels = append(channels, exr.Channel{Name: name, Data: data})
+		}
+	}
+
+	outWriter, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err = exr.Encode(outWriter, width, height, channels); err != nil {
+		outWriter.Close()
+		return err
+	}
+	return outWriter.Close()
+}
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// BenchmarkRender renders the test scenes at 200x100 and 4 samples per
+// pixel with 1 thread up to every CPU, compare runs with benchstat:
+// go test ./render -run - -bench Render -count 5
+func BenchmarkRender(b *testing.B) {
+	scenes, err := filepath.Glob("../test/*.csv")
+	if err != nil || len(scenes) == 0 {
+		b.Fatalf("no test scene: %v", err)
+	}
+	var counts []int
+	for n := 1; n < runtime.NumCPU(); n *= 2 {
+		counts = append(counts, n)
+	}
+	counts = append(counts, runtime.NumCPU())
+
+	for _, scene := range scenes {
+		w, err := LoadScene(scene)
+		if err != nil {
+			b.Fatal(err)
+		}
+		name := strings.TrimSuffix(filepath.Base(scene), ".csv")
+		for _, threads := range counts {
+			b.Run(fmt.Sprintf("%s/threads=%d", name, threads), func(b *testing.B) {
+				o := DefaultOptions()
+				o.Width, o.Height = 200, 100
+				o.Samples, o.NoiseThreshold = 4, 0
+				o.Threads = threads
+				o.Seed = 42
+				if err := o.FocusOn(w); err != nil {
+					b.Fatal(err)
+				}
+				var rays int64
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					s, err := o.NewSampler()
+					if err != nil {
+						b.Fatal(err)
+					}
+					s.SetWorldObj(w)
+					if _, err := s.Render(context.Background()); err != nil {
+						b.Fatal(err)
+					}
+					rays += s.RayStats().Total()
+				}
+				b.ReportMetric(float64(rays)/1e6/b.Elapsed().Seconds(), "Mrays/s")
+			})
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package render
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/Oaklight/Rayerson/ray"
+)
+
+// checkpointVersion is bumped whenever the checkpoint layout changes
+const checkpointVersion = 1
+
+// checkpoint is the on-disk state of an unfinished progressive render.
+// Patterns are deterministic in the seed, the pixel and the sample index,
+// so the seed (part of the settings) and the per pixel sample counts fully
+// restore the random state.
+type checkpoint struct {
+	Version  int
+	Scene    uint64
+	Settings uint64
+	Passes   int
+	Sum      []ray.Color
+	Weight   []float64
+	Samples  []int
+	LumMean  []float64
+	LumM2    []float64
+}
+
+// SaveCheckpoint writes the accumulated film to filePath, replacing any
+// previous checkpoint only once the new one is complete
+func (s *Sampler) SaveCheckpoint(filePath string) error {
+	s.filmLock.Lock()
+	defer s.filmLock.Unlock()
+
+	ck := checkpoint{
+		Version:  checkpointVersion,
+		Scene:    s.sceneHash(),
+		Settings: s.settingsHash(),
+		Passes:   s.passes,
+		Sum:      s.film.sum,
+		Weight:   s.film.weight,
+		Samples:  make([]int, len(s.film.stats)),
+		LumMean:  make([]float64, len(s.film.stats)),
+		LumM2:    make([]float64, len(s.film.stats)),
+	}
+	for i, st := range s.film.stats {
+		ck.Samples[i], ck.LumMean[i], ck.LumM2[i] = st.n, st.lumMean, st.lumM2
+	}
+
+	tmpPath := filePath + ".tmp"
+	outWriter, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	buffered := bufio.NewWriter(outWriter)
+	if err = gob.NewEncoder(buffered).Encode(&ck); err == nil {
+		err = buffered.Flush()
+	}
+	if cerr := outWriter.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+// LoadCheckpoint restores the film saved by SaveCheckpoint, the next
+// progressive render continues after the saved passes. It refuses to load
+// when the world or any render setting differs from the saved render.
+func (s *Sampler) LoadCheckpoint(filePath string) error {
+	inReader, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer inReader.Close()
+
+	var ck checkpoint
+	if err := gob.NewDecoder(bufio.NewReader(inReader)).Decode(&ck); err != nil {
+		return fmt.Errorf("checkpoint %s: %v", filePath, err)
+	}
+	switch {
+	case ck.Version != checkpointVersion:
+		return fmt.Errorf("checkpoint %s: version %d, expect %d", filePath, ck.Version, checkpointVersion)
+	case ck.Scene != s.sceneHash():
+		return fmt.Errorf("checkpoint %s: scene has changed", filePath)
+	case ck.Settings != s.settingsHash():
+		return fmt.Errorf("checkpoint %s: render settings have changed", filePath)
+	case len(ck.Sum) != len(s.film.sum) || len(ck.Samples) != len(s.film.stats):
+		return fmt.Errorf("checkpoint %s: corrupted buffers", filePath)
+	}
+
+	s.filmLock.Lock()
+	defer s.filmLock.Unlock()
+	copy(s.film.sum, ck.Sum)
+	copy(s.film.weight, ck.Weight)
+	for i := range s.film.stats {
+		s.film.stats[i] = pixelStats{n: ck.Samples[i], lumMean: ck.LumMean[i], lumM2: ck.LumM2[i]}
+	}
+	s.passes = ck.Passes
+	return nil
+}
+
+// sceneHash fingerprints every object of the world
+func (s *Sampler) sceneHash() uint64 {
+	h := fnv.New64a()
+	fingerprint(h, reflect.ValueOf(s.world))
+	return h.Sum64()
+}
+
+// settingsHash fingerprints everything besides the world that changes the
+// rendered image
+func (s *Sampler) settingsHash() uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d %d %d %d %g %d %T %v %d %g|",
+		s.width, s.height, s.finess, s.maxDepth, s.tMin, s.seed,
+		s.pattern, s.adaptive, s.minSamples, s.threshold)
+	fingerprint(h, reflect.ValueOf(s.filter))
+	fingerprint(h, reflect.ValueOf(s.cam))
+	return h.Sum64()
+}
+
+// fingerprint writes a canonical description of v, following pointers and
+// interfaces so that the pointed-to values are described instead of their
+// addresses; unexported fields are included
+func fingerprint(w io.Writer, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			fmt.Fprint(w, "nil;")
+			return
+		}
+		fingerprint(w, v.Elem())
+	case reflect.Struct:
+		fmt.Fprintf(w, "%s{", v.Type())
+		for i := 0; i < v.NumField(); i++ {
+			fingerprint(w, v.Field(i))
+		}
+		fmt.Fprint(w, "}")
+	case reflect.Slice, reflect.Array:
+		fmt.Fprintf(w, "[%d:", v.Len())
+		for i := 0; i < v.Len(); i++ {
+			fingerprint(w, v.Index(i))
+		}
+		fmt.Fprint(w, "]")
+	case reflect.Float32, reflect.Float64:
+		fmt.Fprintf(w, "%x;", v.Float())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fmt.Fprintf(w, "%d;", v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fmt.Fprintf(w, "%d;", v.Uint())
+	case reflect.Bool:
+		fmt.Fprintf(w, "%t;", v.Bool())
+	case reflect.String:
+		fmt.Fprintf(w, "%q;", v.String())
+	default:
+		fmt.Fprintf(w, "%s;", v.Kind())
+	}
+}
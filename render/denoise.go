@@ -0,0 +1,173 @@
+package render
+
+import (
+	"errors"
+	"math"
+
+	"github.com/Oaklight/Rayerson/ray"
+)
+
+// DenoiseNames lists the denoisers, none keeps the image as rendered
+var DenoiseNames = []string{"none", "atrous"}
+
+// DenoiseOptions configures the edge-aware à-trous wavelet filter of
+// Denoise. Every sigma is the difference at which a neighbour weighs
+// exp(-1) of a matching one, larger sigmas blur across more edges.
+type DenoiseOptions struct {
+	// Iterations of the 5x5 kernel, whose taps spread twice as far each
+	// time, 5 covers 125 pixels wide
+	Iterations int
+	// SigmaColor bounds color differences, halved every iteration so the
+	// wide passes only smooth what the first ones left flat
+	SigmaColor float64
+	// SigmaNormal bounds the distance between unit normals
+	SigmaNormal float64
+	// SigmaDepth bounds depth differences relative to the pixel depth
+	SigmaDepth float64
+	// SigmaAlbedo bounds albedo differences
+	SigmaAlbedo float64
+}
+
+// DefaultDenoiseOptions returns settings fit for low sample counts
+func DefaultDenoiseOptions() DenoiseOptions {
+	return DenoiseOptions{
+		Iterations:  5,
+		SigmaColor:  1,
+		SigmaNormal: 0.3,
+		SigmaDepth:  0.05,
+		SigmaAlbedo: 0.1,
+	}
+}
+
+// atrousKernel is the B3 spline of the à-trous transform
+var atrousKernel = [5]float64{1.0 / 16, 1.0 / 4, 3.0 / 8, 1.0 / 4, 1.0 / 16}
+
+// Denoise filters the beauty layer, guided by the first hit layers so that
+// silhouettes, creases and material boundaries stay sharp. Lighting is
+// filtered apart from the albedo, which multiplies it back afterwards.
+func Denoise(beauty, albedo, normal, depth *Layer, o DenoiseOptions) *Layer {
+	w, h := beauty.Width, beauty.Height
+	n := w * h
+	// demodulate the albedo of pixels hitting something
+	cur := make([][3]float64, n)
+	for i := range cur {
+		b, a := beauty.Pix[3*i:3*i+3], albedo.Pix[3*i:3*i+3]
+		for c := 0; c < 3; c++ {
+			cur[i][c] = b[c]
+			if !math.IsInf(depth.Pix[i], 1) && a[c] > 1e-3 {
+				cur[i][c] /= a[c]
+			}
+		}
+	}
+
+	next := make([][3]float64, n)
+	sigmaColor := o.SigmaColor
+	for it := 0; it < o.Iterations; it++ {
+		step := 1 << uint(it)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				p := y*w + x
+				var sum [3]float64
+				var total float64
+				for ky := 0; ky < 5; ky++ {
+					qy := y + (ky-2)*step
+					if qy < 0 || qy >= h {
+						continue
+					}
+					for kx := 0; kx < 5; kx++ {
+						qx := x + (kx-2)*step
+						if qx < 0 || qx >= w {
+							continue
+						}
+						q := qy*w + qx
+						weight := atrousKernel[kx] * atrousKernel[ky] *
+							gauss(dist2(cur[p][:], cur[q][:]), sigmaColor) *
+							gauss(dist2(normal.Pix[3*p:3*p+3], normal.Pix[3*q:3*q+3]), o.SigmaNormal) *
+							gauss(dist2(albedo.Pix[3*p:3*p+3], albedo.Pix[3*q:3*q+3]), o.SigmaAlbedo) *
+							depthWeight(depth.Pix[p], depth.Pix[q], o.SigmaDepth)
+						for c := 0; c < 3; c++ {
+							sum[c] += weight * cur[q][c]
+						}
+						total += weight
+					}
+				}
+				// the center tap always weighs something
+				for c := 0; c < 3; c++ {
+					next[p][c] = sum[c] / total
+				}
+			}
+		}
+		cur, next = next, cur
+		sigmaColor /= 2
+	}
+
+	out := newLayer(beauty.Name, w, h, beauty.Channels...)
+	for i := range cur {
+		a := albedo.Pix[3*i : 3*i+3]
+		for c := 0; c < 3; c++ {
+			v := cur[i][c]
+			if !math.IsInf(depth.Pix[i], 1) && a[c] > 1e-3 {
+				v *= a[c]
+			}
+			out.Pix[3*i+c] = v
+		}
+	}
+	return out
+}
+
+func dist2(a, b []float64) float64 {
+	var d float64
+	for i := range a {
+		d += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return d
+}
+
+// gauss weighs a squared distance d2 against sigma
+func gauss(d2, sigma float64) float64 {
+	return math.Exp(-d2 / (sigma * sigma))
+}
+
+// depthWeight compares depths relative to dp, pixels seeing nothing only
+// match each other
+func depthWeight(dp, dq, sigma float64) float64 {
+	switch inf := math.IsInf(dp, 1); {
+	case inf && math.IsInf(dq, 1):
+		return 1
+	case inf || math.IsInf(dq, 1):
+		return 0
+	}
+	d := (dp - dq) / (sigma * math.Max(dp, 1e-6))
+	return math.Exp(-d * d)
+}
+
+// Denoise filters the rendered image in place with the albedo, normal and
+// depth buffers, which must have been kept by EnableAOVs. Beauty keeps
+// returning the image as rendered, Denoised the filtered one.
+func (s *Sampler) Denoise(o DenoiseOptions) error {
+	if s.aov == nil {
+		return errors.New("denoising needs the first hit buffers, enable them before rendering")
+	}
+	guides := map[string]*Layer{}
+	for _, l := range s.layers([]string{"albedo", "normal", "depth"}) {
+		guides[l.Name] = l
+	}
+	beauty := s.Beauty()
+	denoised := Denoise(beauty, guides["albedo"], guides["normal"], guides["depth"], o)
+	denoised.Name = "denoised"
+	s.denoised = denoised
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; x++ {
+			px := denoised.At(x, y)
+			c := ray.Color{R: px[0], G: px[1], B: px[2]}
+			s.ImgOut.SetRGBA64(x, y, c.RGBA64())
+		}
+	}
+	return nil
+}
+
+// Denoised returns the image filtered by the last Denoise as linear colors,
+// nil before any
+func (s *Sampler) Denoised() *Layer {
+	return s.denoised
+}
@@ -0,0 +1,136 @@
+package render
+
+import (
+	"context"
+	"testing"
+
+	pm "github.com/Oaklight/Rayerson/primitives"
+	"github.com/Oaklight/Rayerson/ray"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+func testWorld() *pm.World {
+	w := &pm.World{}
+	w.Add(
+		pm.NewSphere(0, -1000, 0, 1000, pm.NewDiffuse(ray.NewColor(0.5, 0.5, 0.5))),
+		pm.NewSphere(0, 1, 0, 1, pm.NewDielectric(1.5)),
+		pm.NewSphere(-4, 1, 0, 1, pm.NewDiffuse(ray.NewColor(0.4, 0.2, 0.1))),
+		pm.NewSphere(4, 1, 0, 1, pm.NewMetallic(ray.NewColor(0.7, 0.6, 0.5), 0.1)),
+		pm.NewSphere(2, 0.5, 3, 0.5, pm.NewDiffuse(ray.NewColor(0.1, 0.6, 0.2))),
+	)
+	return w
+}
+
+// renderTest renders the test world at spp samples per pixel
+func renderTest(t *testing.T, spp int, seed int64, denoise string) *Sampler {
+	o := DefaultOptions()
+	o.Width, o.Height = 48, 24
+	o.Samples, o.NoiseThreshold = spp, 0
+	o.Seed = seed
+	o.Threads = 0
+	o.Aperture = 0
+	o.Pos, o.LookAt = vec3.Vec3{X: 13, Y: 2, Z: 3}, vec3.Vec3{}
+	o.FOV = 30
+	o.Denoise = denoise
+	if err := o.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := o.NewSampler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetWorldObj(testWorld())
+	if _, err := s.Render(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// layerMSE compares the clamped linear colors of two layers
+func layerMSE(a, b *Layer) float64 {
+	var sum float64
+	for i := range a.Pix {
+		d := clamp01(a.Pix[i]) - clamp01(b.Pix[i])
+		sum += d * d
+	}
+	return sum / float64(len(a.Pix))
+}
+
+func clamp01(v float64) float64 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	}
+	return v
+}
+
+func TestDenoiseReducesError(t *testing.T) {
+	reference := renderTest(t, 1024, 1, "none").Beauty()
+	noisy := renderTest(t, 1, 2, "atrous")
+
+	guides := map[string]*Layer{}
+	for _, l := range noisy.layers([]string{"albedo", "normal", "depth"}) {
+		guides[l.Name] = l
+	}
+	before := noisy.Beauty()
+	after := Denoise(before, guides["albedo"], guides["normal"], guides["depth"], DefaultDenoiseOptions())
+
+	noisyErr, denoisedErr := layerMSE(before, reference), layerMSE(after, reference)
+	t.Logf("MSE against 1024 spp: %.5f noisy, %.5f denoised", noisyErr, denoisedErr)
+	// edges of a 48x24 image keep some error no filter can remove
+	if denoisedErr > noisyErr*2/3 {
+		t.Errorf("denoised MSE %.5f, expect at most two thirds of the noisy %.5f", denoisedErr, noisyErr)
+	}
+}
+
+func TestDenoiseKeepsFlatImage(t *testing.T) {
+	l := newLayer("beauty", 8, 8, "R", "G", "B")
+	albedo := newLayer("albedo", 8, 8, "R", "G", "B")
+	normal := newLayer("normal", 8, 8, "X", "Y", "Z")
+	depth := newLayer("depth", 8, 8, "Z")
+	for i := range l.Pix {
+		l.Pix[i], albedo.Pix[i] = 0.25, 0.5
+	}
+	for i := range depth.Pix {
+		depth.Pix[i] = 3
+		normal.Pix[3*i+1] = 1
+	}
+	out := Denoise(l, albedo, normal, depth, DefaultDenoiseOptions())
+	for i, v := range out.Pix {
+		if v < 0.25-1e-9 || v > 0.25+1e-9 {
+			t.Fatalf("value %d changed to %g, expect 0.25", i, v)
+		}
+	}
+}
+
+func TestSamplerDenoiseNeedsBuffers(t *testing.T) {
+	s := NewSampler(4, 4, 1, 1, 0.001)
+	if err := s.Denoise(DefaultDenoiseOptions()); err == nil {
+		t.Error("expect an error without first hit buffers")
+	}
+}
+
+// TestSamplerDenoiseKeepsBeauty checks the rendered image stays available
+// beside the denoised one, as the EXR output writes both
+func TestSamplerDenoiseKeepsBeauty(t *testing.T) {
+	s := renderTest(t, 2, 1, "atrous")
+	before := s.Beauty()
+	if s.Denoised() != nil {
+		t.Fatal("expect no denoised layer before Denoise")
+	}
+	if err := s.Denoise(DefaultDenoiseOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if mse := layerMSE(s.Beauty(), before); mse != 0 {
+		t.Errorf("beauty changed by denoising, MSE %g", mse)
+	}
+	d := s.Denoised()
+	if d == nil || d.Name != "denoised" {
+		t.Fatalf("expect a layer named denoised, got %v", d)
+	}
+	if layerMSE(d, before) == 0 {
+		t.Error("denoised layer equals the beauty")
+	}
+}
@@ -0,0 +1,30 @@
+package render
+
+import (
+	"github.com/Oaklight/Rayerson/ray"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// Environment gives the radiance reaching the camera along rays escaping
+// the world, by their direction
+type Environment func(direct *vec3.Vec3) *ray.Color
+
+// Sky is the default environment, a gradient from white below the horizon
+// to black straight up
+func Sky(direct *vec3.Vec3) *ray.Color {
+	t := 0.5 * (direct.Normalize().Y + 1)
+	return ray.Transparent.MulScalar(1.0 - t).Add(ray.Opaque.MulScalar(t))
+}
+
+// UniformEnvironment returns the same radiance c from everywhere, in which
+// objects neither absorbing nor emitting light disappear
+func UniformEnvironment(c ray.Color) Environment {
+	return func(*vec3.Vec3) *ray.Color {
+		return &ray.Color{R: c.R, G: c.G, B: c.B}
+	}
+}
+
+// SetEnvironment replaces the Sky surrounding the world
+func (s *Sampler) SetEnvironment(env Environment) {
+	s.env = env
+}
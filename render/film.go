@@ -0,0 +1,175 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/Oaklight/Rayerson/ray"
+)
+
+// pixelStats keeps the Welford mean and variance of the luminance of the
+// samples taken inside one pixel, it drives adaptive sampling
+type pixelStats struct {
+	n       int
+	lumMean float64
+	lumM2   float64
+}
+
+// film is the float framebuffer every sample is splatted into, holding the
+// filter weighted radiance sum and the accumulated filter weight per pixel.
+// bounds follows the sampler convention of y growing upwards; tiles render
+// into small films of their own that are merged into the image film.
+type film struct {
+	bounds image.Rectangle
+	sum    []ray.Color
+	weight []float64
+	// stats is only allocated for the image film
+	stats []pixelStats
+}
+
+func newFilm(bounds image.Rectangle, withStats bool) *film {
+	n := bounds.Dx() * bounds.Dy()
+	f := &film{
+		bounds: bounds,
+		sum:    make([]ray.Color, n),
+		weight: make([]float64, n),
+	}
+	if withStats {
+		f.stats = make([]pixelStats, n)
+	}
+	return f
+}
+
+func (f *film) index(x, y int) int {
+	return (y-f.bounds.Min.Y)*f.bounds.Dx() + x - f.bounds.Min.X
+}
+
+// splat adds color c sampled at the continuous position (px, py) to every
+// pixel of the film within the filter radius
+func (f *film) splat(filter Filter, px, py float64, c *ray.Color) {
+	r := filter.Radius()
+	x0 := maxInt(f.bounds.Min.X, int(math.Ceil(px-0.5-r)))
+	x1 := minInt(f.bounds.Max.X-1, int(math.Floor(px-0.5+r)))
+	y0 := maxInt(f.bounds.Min.Y, int(math.Ceil(py-0.5-r)))
+	y1 := minInt(f.bounds.Max.Y-1, int(math.Floor(py-0.5+r)))
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			w := filter.Evaluate(float64(x)+0.5-px, float64(y)+0.5-py)
+			if w == 0 {
+				continue
+			}
+			i := f.index(x, y)
+			f.sum[i].R += c.R * w
+			f.sum[i].G += c.G * w
+			f.sum[i].B += c.B * w
+			f.weight[i] += w
+		}
+	}
+}
+
+// merge adds the splats of a tile film into f
+func (f *film) merge(tile *film) {
+	b := tile.bounds.Intersect(f.bounds)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			i, j := f.index(x, y), tile.index(x, y)
+			f.sum[i].R += tile.sum[j].R
+			f.sum[i].G += tile.sum[j].G
+			f.sum[i].B += tile.sum[j].B
+			f.weight[i] += tile.weight[j]
+		}
+	}
+}
+
+func (f *film) stat(x, y int) *pixelStats {
+	return &f.stats[f.index(x, y)]
+}
+
+// addStats records the luminance of a sample taken inside pixel (x, y)
+func (f *film) addStats(x, y int, c *ray.Color) {
+	p := &f.stats[f.index(x, y)]
+	p.n++
+	lum := luminance(c)
+	delta := lum - p.lumMean
+	p.lumMean += delta / float64(p.n)
+	p.lumM2 += delta * (lum - p.lumMean)
+}
+
+// samples returns how many samples were taken inside pixel (x, y)
+func (f *film) samples(x, y int) int {
+	return f.stats[f.index(x, y)].n
+}
+
+// unconverged counts the pixels that still exceed threshold
+func (f *film) unconverged(threshold float64) int {
+	count := 0
+	for y := f.bounds.Min.Y; y < f.bounds.Max.Y; y++ {
+		for x := f.bounds.Min.X; x < f.bounds.Max.X; x++ {
+			if !f.converged(x, y, threshold) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// color returns the normalized radiance of pixel (x, y)
+func (f *film) color(x, y int) *ray.Color {
+	i := f.index(x, y)
+	if f.weight[i] == 0 {
+		return &ray.Color{}
+	}
+	return f.sum[i].DivScalar(f.weight[i])
+}
+
+// converged reports whether the 95% confidence interval of the pixel mean
+// luminance is narrower than threshold relative to the mean itself
+func (f *film) converged(x, y int, threshold float64) bool {
+	p := &f.stats[f.index(x, y)]
+	if p.n < 2 {
+		return false
+	}
+	variance := p.lumM2 / float64(p.n-1)
+	interval := 1.96 * math.Sqrt(variance/float64(p.n))
+	// the floor keeps near black pixels from chasing a vanishing target
+	return interval <= threshold*math.Max(p.lumMean, 1e-2)
+}
+
+// heatmap maps the samples spent per pixel onto a black, red, yellow,
+// white ramp, where white means maxSamples
+func (f *film) heatmap(maxSamples int) *image.RGBA {
+	w, h := f.bounds.Dx(), f.bounds.Dy()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			t := math.Min(1, float64(f.stats[f.index(x, y)].n)/float64(maxSamples))
+			r := math.Min(1, 3*t)
+			g := math.Min(1, math.Max(0, 3*t-1))
+			b := math.Max(0, 3*t-2)
+			img.SetRGBA(x, h-1-y, color.RGBA{
+				uint8(r * 255), uint8(g * 255), uint8(b * 255), 255,
+			})
+		}
+	}
+	return img
+}
+
+// luminance uses the Rec. 709 weights
+func luminance(c *ray.Color) float64 {
+	return 0.2126*c.R + 0.7152*c.G + 0.0722*c.B
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,163 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Filter is a pixel reconstruction filter, every sample is splatted into
+// all pixels whose center lies within Radius, weighted by Evaluate
+type Filter interface {
+	Radius() float64
+	// Evaluate returns the weight of a sample at offset (x, y) from the
+	// pixel center, it may be negative for filters with negative lobes
+	Evaluate(x, y float64) float64
+}
+
+// FilterNames lists the filters understood by NewFilter
+var FilterNames = []string{"box", "tent", "gaussian", "mitchell", "lanczos"}
+
+// NewFilter creates the filter called name, a non-positive radius picks
+// the customary radius for that filter
+func NewFilter(name string, radius float64) (Filter, error) {
+	pick := func(def float64) float64 {
+		if radius > 0 {
+			return radius
+		}
+		return def
+	}
+	switch strings.ToLower(name) {
+	case "box":
+		return &BoxFilter{pick(0.5)}, nil
+	case "tent", "triangle":
+		return &TentFilter{pick(1)}, nil
+	case "gaussian":
+		return NewGaussianFilter(pick(1.5), 2), nil
+	case "mitchell":
+		return &MitchellFilter{R: pick(2), B: 1.0 / 3, C: 1.0 / 3}, nil
+	case "lanczos":
+		return &LanczosFilter{pick(3)}, nil
+	}
+	return nil, fmt.Errorf("unknown filter %q, expect one of %s",
+		name, strings.Join(FilterNames, ", "))
+}
+
+// ========================= BoxFilter =========================
+
+// BoxFilter weights every sample equally, with radius 0.5 this is plain
+// per-pixel averaging
+type BoxFilter struct {
+	R float64
+}
+
+func (f *BoxFilter) Radius() float64 {
+	return f.R
+}
+
+func (f *BoxFilter) Evaluate(x, y float64) float64 {
+	// half-open so that a radius of 0.5 partitions the image exactly
+	if x > -f.R && x <= f.R && y > -f.R && y <= f.R {
+		return 1
+	}
+	return 0
+}
+
+// ========================= TentFilter =========================
+
+// TentFilter falls off linearly towards its radius
+type TentFilter struct {
+	R float64
+}
+
+func (f *TentFilter) Radius() float64 {
+	return f.R
+}
+
+func (f *TentFilter) Evaluate(x, y float64) float64 {
+	return math.Max(0, f.R-math.Abs(x)) * math.Max(0, f.R-math.Abs(y))
+}
+
+// ========================= GaussianFilter =========================
+
+// GaussianFilter is a gaussian shifted down to reach zero at its radius
+type GaussianFilter struct {
+	R, Alpha float64
+	edge     float64
+}
+
+// NewGaussianFilter creates a gaussian filter with falloff alpha
+func NewGaussianFilter(radius, alpha float64) *GaussianFilter {
+	return &GaussianFilter{R: radius, Alpha: alpha, edge: math.Exp(-alpha * radius * radius)}
+}
+
+func (f *GaussianFilter) Radius() float64 {
+	return f.R
+}
+
+func (f *GaussianFilter) gaussian(d float64) float64 {
+	return math.Max(0, math.Exp(-f.Alpha*d*d)-f.edge)
+}
+
+func (f *GaussianFilter) Evaluate(x, y float64) float64 {
+	return f.gaussian(x) * f.gaussian(y)
+}
+
+// ========================= MitchellFilter =========================
+
+// MitchellFilter is the Mitchell-Netravali cubic, B = C = 1/3 is the
+// recommended balance between ringing and blurring
+type MitchellFilter struct {
+	R, B, C float64
+}
+
+func (f *MitchellFilter) Radius() float64 {
+	return f.R
+}
+
+func (f *MitchellFilter) mitchell(d float64) float64 {
+	// the cubic is defined on [-2, 2]
+	x := math.Abs(2 * d / f.R)
+	b, c := f.B, f.C
+	switch {
+	case x > 2:
+		return 0
+	case x > 1:
+		return ((-b-6*c)*x*x*x + (6*b+30*c)*x*x + (-12*b-48*c)*x + (8*b + 24*c)) / 6
+	}
+	return ((12-9*b-6*c)*x*x*x + (-18+12*b+6*c)*x*x + (6 - 2*b)) / 6
+}
+
+func (f *MitchellFilter) Evaluate(x, y float64) float64 {
+	return f.mitchell(x) * f.mitchell(y)
+}
+
+// ========================= LanczosFilter =========================
+
+// LanczosFilter is a sinc windowed by a wider sinc, its radius is also the
+// number of lobes kept
+type LanczosFilter struct {
+	R float64
+}
+
+func (f *LanczosFilter) Radius() float64 {
+	return f.R
+}
+
+func (f *LanczosFilter) lanczos(d float64) float64 {
+	if math.Abs(d) >= f.R {
+		return 0
+	}
+	return sinc(d) * sinc(d/f.R)
+}
+
+func (f *LanczosFilter) Evaluate(x, y float64) float64 {
+	return f.lanczos(x) * f.lanczos(y)
+}
+
+func sinc(x float64) float64 {
+	if math.Abs(x) < 1e-5 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	pm "github.com/Oaklight/Rayerson/primitives"
+	"github.com/Oaklight/Rayerson/ray"
+	"github.com/Oaklight/Rayerson/sampling"
+)
+
+// autofocusPixel parses Autofocus, "center" or a pixel "x,y" counted from
+// the top left corner of the image
+func (o *Options) autofocusPixel() (image.Point, error) {
+	if o.Autofocus == "center" {
+		return image.Pt(o.Width/2, o.Height/2), nil
+	}
+	parts := strings.Split(o.Autofocus, ",")
+	if len(parts) != 2 {
+		return image.Point{}, errors.New("expect x,y or center")
+	}
+	var xy [2]int
+	for i, p := range parts {
+		var err error
+		if xy[i], err = strconv.Atoi(strings.TrimSpace(p)); err != nil {
+			return image.Point{}, fmt.Errorf("expect x,y or center: %v", err)
+		}
+	}
+	pt := image.Pt(xy[0], xy[1])
+	if !pt.In(image.Rect(0, 0, o.Width, o.Height)) {
+		return image.Point{}, fmt.Errorf("pixel %v is outside the %dx%d image", pt, o.Width, o.Height)
+	}
+	return pt, nil
+}
+
+// FocusOn sets FocusDist to the distance, along the view direction, of the
+// surface world shows at the autofocus pixel. It does nothing without
+// Autofocus.
+func (o *Options) FocusOn(world pm.Hitable) error {
+	if o.Autofocus == "" {
+		return nil
+	}
+	pt, err := o.autofocusPixel()
+	if err != nil {
+		return err
+	}
+
+	// a pinhole sees the pixel sharp, whatever the focus
+	fov, _ := o.lens()
+	aspect := float64(o.Width) / float64(o.Height)
+	pinhole := ray.NewPerspectiveCamera(fov, aspect, 0, 0, o.Pos, o.LookAt, o.Up)
+	u := (float64(pt.X) + 0.5) / float64(o.Width)
+	v := 1 - (float64(pt.Y)+0.5)/float64(o.Height)
+	r := pinhole.GetRay(u, v, sampling.NewIndependent(o.Seed))
+
+	hit := world.Hit(r, o.TMin, math.MaxFloat64)
+	if hit == nil {
+		return fmt.Errorf("autofocus: pixel %d,%d sees no object", pt.X, pt.Y)
+	}
+	view := o.LookAt.Sub(&o.Pos).Normalize()
+	o.FocusDist = hit.Point.Sub(&o.Pos).Dot(view)
+	return nil
+}
@@ -0,0 +1,69 @@
+package render
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	pm "github.com/Oaklight/Rayerson/primitives"
+	"github.com/Oaklight/Rayerson/ray"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// TestWhiteFurnace renders a sphere in a uniform environment: each bounce
+// of a material that neither emits nor absorbs beyond its albedo keeps the
+// radiance, and as light leaves a sphere only once from outside, every
+// pixel must be the environment times the albedo.
+func TestWhiteFurnace(t *testing.T) {
+	const radiance = 0.5
+	gray := func(v float64) *ray.Color { return ray.NewColor(v, v, v) }
+	cases := []struct {
+		name     string
+		material pm.Materials
+		albedo   float64
+	}{
+		{"white diffuse", pm.NewDiffuse(gray(1)), 1},
+		{"gray diffuse", pm.NewDiffuse(gray(0.6)), 0.6},
+		{"mirror", pm.NewMetallic(gray(1), 0), 1},
+		{"fuzzy metal", pm.NewMetallic(gray(1), 0.3), 1},
+		{"fuzziest metal", pm.NewMetallic(gray(1), 1), 1},
+		{"gray metal", pm.NewMetallic(gray(0.8), 0.5), 0.8},
+		{"glass", pm.NewDielectric(1.5), 1},
+		{"dense glass", pm.NewDielectric(2.4), 1},
+	}
+	for _, c := range cases {
+		// the sphere covers 19.5 degrees around the view axis, more than
+		// the diagonal of the narrow view, the wide one also sees grazing
+		// hits, where only materials of albedo 1 vanish against the
+		// environment
+		fovs := []float64{20, 60}
+		if c.albedo < 1 {
+			fovs = fovs[:1]
+		}
+		for _, fov := range fovs {
+			o := DefaultOptions()
+			o.Width, o.Height = 16, 16
+			o.Samples, o.NoiseThreshold = 16, 0
+			o.Aperture = 0
+			o.Pos, o.LookAt, o.FOV = vec3.Vec3{Z: 3}, vec3.Vec3{}, fov
+			s, err := o.NewSampler()
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.SetWorldObj(&pm.World{pm.NewSphere(0, 0, 0, 1, c.material)})
+			s.SetEnvironment(UniformEnvironment(*gray(radiance)))
+			if _, err := s.Render(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			want := radiance * c.albedo
+			var worst float64
+			for _, v := range s.Beauty().Pix {
+				worst = math.Max(worst, math.Abs(v-want))
+			}
+			if worst > 1e-9 {
+				t.Errorf("%s, %g degrees view: radiance off by up to %g, want %g everywhere", c.name, fov, worst, want)
+			}
+		}
+	}
+}
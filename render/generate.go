@@ -0,0 +1,141 @@
+package render
+
+import (
+	"math"
+	"math/rand"
+
+	pm "github.com/Oaklight/Rayerson/primitives"
+	"github.com/Oaklight/Rayerson/ray"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// GeneratorOptions configures GenerateScene
+type GeneratorOptions struct {
+	// Seed makes the scene reproducible, the same options always give
+	// the same scene
+	Seed int64
+	// Grid places one small sphere per unit cell in [-Grid, Grid) along
+	// x and z, moved around within the cell by up to Jitter
+	Grid   int
+	Jitter float64
+	// RadiusMin and RadiusMax bound the uniform distribution of the
+	// radius of small spheres, they rest on the floor at y = 0
+	RadiusMin, RadiusMax float64
+	// Diffuse and Metallic are the probabilities of each material, the
+	// remaining spheres are glass
+	Diffuse, Metallic float64
+	// FuzzMin and FuzzMax bound the fuzziness of metals
+	FuzzMin, FuzzMax float64
+	// RefIdx is the refractive index of glass
+	RefIdx float64
+	// Floor adds a huge diffuse sphere acting as the ground
+	Floor bool
+	// Heroes are placed before the small spheres
+	Heroes []pm.Hitable
+	// AvoidOverlap drops small spheres intersecting any placed object
+	AvoidOverlap bool
+}
+
+// DefaultHeroes returns the three large spheres of the classic scene
+func DefaultHeroes() []pm.Hitable {
+	return []pm.Hitable{
+		pm.NewSphere(0, 1, 0, 1.0, pm.NewDielectric(1.5)),
+		pm.NewSphere(-4, 1, 0, 1.0, pm.NewDiffuse(&ray.Color{R: 0.4, G: 0, B: 0.1})),
+		pm.NewSphere(4, 1, 0, 1.0, pm.NewMetallic(&ray.Color{R: 0.7, G: 0.6, B: 0.5}, 0)),
+	}
+}
+
+// DefaultGeneratorOptions returns the options of the classic random scene
+func DefaultGeneratorOptions() GeneratorOptions {
+	return GeneratorOptions{
+		Seed:         42,
+		Grid:         11,
+		Jitter:       0.9,
+		RadiusMin:    0.2,
+		RadiusMax:    0.2,
+		Diffuse:      0.8,
+		Metallic:     0.15,
+		FuzzMin:      0.5,
+		FuzzMax:      1,
+		RefIdx:       1.5,
+		Floor:        true,
+		Heroes:       DefaultHeroes(),
+		AvoidOverlap: true,
+	}
+}
+
+// placed is the bounding sphere of an object already in the scene
+type placed struct {
+	center vec3.Vec3
+	radius float64
+}
+
+func boundingSphere(h pm.Hitable) placed {
+	if s, ok := h.(*pm.Sphere); ok {
+		return placed{*s.Center, math.Abs(s.Radius)}
+	}
+	b := h.Bounds()
+	return placed{*b.Min.Add(&b.Max).MulScalar(0.5), b.Size().Length() / 2}
+}
+
+// GenerateScene builds a random scene of small spheres around the heroes,
+// it only depends on opts
+func GenerateScene(opts GeneratorOptions) *pm.World {
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	uniform := func(lo, hi float64) float64 {
+		return lo + (hi-lo)*rnd.Float64()
+	}
+
+	world := pm.World{}
+	if opts.Floor {
+		world.Add(pm.NewSphere(0, -1000, -1, 1000, pm.NewDiffuse(ray.NewColor(0.5, 0.5, 0.5))))
+	}
+	var occupied []placed
+	for _, h := range opts.Heroes {
+		world.Add(h)
+		occupied = append(occupied, boundingSphere(h))
+	}
+
+	for a := -opts.Grid; a < opts.Grid; a++ {
+		for b := -opts.Grid; b < opts.Grid; b++ {
+			// every cell draws the same amount of numbers, whether its
+			// sphere is kept or not, so the other cells stay put
+			material := rnd.Float64()
+			radius := uniform(opts.RadiusMin, opts.RadiusMax)
+			center := vec3.Vec3{
+				X: float64(a) + opts.Jitter*rnd.Float64(),
+				Y: radius,
+				Z: float64(b) + opts.Jitter*rnd.Float64(),
+			}
+			c1, c2, c3, c4 := rnd.Float64(), rnd.Float64(), rnd.Float64(), rnd.Float64()
+			c5, c6 := rnd.Float64(), rnd.Float64()
+
+			var m pm.Materials
+			switch {
+			case material < opts.Diffuse:
+				m = pm.NewDiffuse(&ray.Color{R: c1 * c2, G: c3 * c4, B: c5 * c6})
+			case material < opts.Diffuse+opts.Metallic:
+				m = pm.NewMetallic(&ray.Color{R: 0.5 * (1 + c1), G: 0.5 * (1 + c2), B: 0.5 * (1 + c3)},
+					opts.FuzzMin+(opts.FuzzMax-opts.FuzzMin)*c4)
+			default:
+				m = pm.NewDielectric(opts.RefIdx)
+			}
+
+			if opts.AvoidOverlap && overlaps(center, radius, occupied) {
+				continue
+			}
+			world.Add(pm.NewSphere(center.X, center.Y, center.Z, radius, m))
+			occupied = append(occupied, placed{center, radius})
+		}
+	}
+	return &world
+}
+
+func overlaps(center vec3.Vec3, radius float64, occupied []placed) bool {
+	for _, o := range occupied {
+		if center.Sub(&o.center).Length() < radius+o.radius {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package render
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// generated saves the scene of opts in the format of ext and returns the file
+func generated(t *testing.T, opts GeneratorOptions, name, ext string) []byte {
+	filePath := filepath.Join(t.TempDir(), name+ext)
+	if err := SaveScene(filePath, GenerateScene(opts)); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestGenerateSceneReproducible(t *testing.T) {
+	opts := DefaultGeneratorOptions()
+	other := DefaultGeneratorOptions()
+	other.Seed++
+	for _, ext := range []string{".csv", ".json"} {
+		first := generated(t, opts, "first", ext)
+		if !bytes.Equal(generated(t, DefaultGeneratorOptions(), "second", ext), first) {
+			t.Errorf("%s: the same options saved different scenes", ext)
+		}
+		if bytes.Equal(generated(t, other, "other", ext), first) {
+			t.Errorf("%s: seeds %d and %d saved the same scene", ext, opts.Seed, other.Seed)
+		}
+	}
+}
+
+func TestRandomSceneError(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "missing", "scene.csv")
+	if w, err := RandomScene(filePath); err == nil || w != nil {
+		t.Errorf("writing into a missing directory gives %v, %v, expect an error", w, err)
+	}
+}
@@ -0,0 +1,77 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pm "github.com/Oaklight/Rayerson/primitives"
+)
+
+// SceneInfo summarizes the content of a world
+type SceneInfo struct {
+	Objects   map[string]int
+	Materials map[string]int
+	Bounds    pm.AABB
+}
+
+// DescribeScene counts the objects and materials of w, by type
+func DescribeScene(w *pm.World) SceneInfo {
+	info := SceneInfo{
+		Objects:   map[string]int{},
+		Materials: map[string]int{},
+		Bounds:    w.Bounds(),
+	}
+	for _, each := range *w {
+		if each == nil {
+			continue
+		}
+		info.Objects[typeName(each, "")]++
+		if s, ok := each.(*pm.Sphere); ok {
+			info.Materials[typeName(s.Material, "Material")]++
+		}
+	}
+	return info
+}
+
+// typeName returns the bare type name of v, without suffix
+func typeName(v interface{}, suffix string) string {
+	name := fmt.Sprintf("%T", v)
+	name = name[strings.LastIndex(name, ".")+1:]
+	return strings.TrimSuffix(name, suffix)
+}
+
+func (info SceneInfo) String() string {
+	var b strings.Builder
+	count := func(title string, m map[string]int) {
+		total := 0
+		names := make([]string, 0, len(m))
+		for name, n := range m {
+			names = append(names, name)
+			total += n
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&b, "%-10s %d", title, total)
+		for i, name := range names {
+			sep := ", "
+			if i == 0 {
+				sep = " ("
+			}
+			fmt.Fprintf(&b, "%s%s %d", sep, name, m[name])
+		}
+		if len(names) > 0 {
+			b.WriteString(")")
+		}
+		b.WriteString("\n")
+	}
+	count("objects", info.Objects)
+	count("materials", info.Materials)
+	if info.Bounds.IsEmpty() {
+		fmt.Fprintf(&b, "%-10s empty\n", "bounds")
+	} else {
+		min, max, size := info.Bounds.Min, info.Bounds.Max, info.Bounds.Size()
+		fmt.Fprintf(&b, "%-10s (%g, %g, %g) to (%g, %g, %g), size %g x %g x %g\n", "bounds",
+			min.X, min.Y, min.Z, max.X, max.Y, max.Z, size.X, size.Y, size.Z)
+	}
+	return b.String()
+}
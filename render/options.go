@@ -0,0 +1,482 @@
+package render
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Oaklight/Rayerson/ray"
+	"github.com/Oaklight/Rayerson/sampling"
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// Options gathers every setting of a render, as given on the command line
+type Options struct {
+	Scene, Output string
+
+	// image and sampling
+	Width, Height     int
+	Samples, MaxDepth int
+	TMin              float64
+	Seed              int64
+	Threads           int
+	Pattern           string
+	Filter            string
+	FilterRadius      float64
+	MinSamples        int
+	NoiseThreshold    float64
+	Heatmap           string
+	AOVs              string
+	AOVFormat         string
+	Denoise           string
+	DenoiseIterations int
+
+	// progressive rendering and checkpoints
+	Progressive      bool
+	SnapshotEvery    int
+	SnapshotPeriod   time.Duration
+	TimeBudget       time.Duration
+	Checkpoint       string
+	CheckpointEvery  int
+	CheckpointPeriod time.Duration
+	Resume           bool
+
+	// camera
+	Projection      string
+	Pos, LookAt, Up vec3.Vec3
+	FOV             float64
+	OrthoHeight     float64
+	Aperture        float64
+	FocusDist       float64
+	FocalLength     float64
+	FNumber         float64
+	Autofocus       string
+	// bokeh
+	ApertureBlades   int
+	ApertureRotation float64
+	ApertureMask     string
+
+	// stereo and multi-view rigs
+	Views       int
+	Interocular float64
+	Convergence float64
+	Rig         string
+	Layout      string
+
+	// profiles written for go tool pprof and go tool trace
+	CPUProfile   string
+	MemProfile   string
+	AllocProfile string
+	Trace        string
+
+	// viewShift is the off-axis shift of one view of a parallel rig
+	viewShift float64
+}
+
+// DefaultOptions returns the settings used when no flag is given
+func DefaultOptions() *Options {
+	return &Options{
+		Width:             800,
+		Height:            400,
+		Samples:           100,
+		MaxDepth:          50,
+		TMin:              0.001,
+		Seed:              42,
+		Threads:           1,
+		Pattern:           "sobol",
+		Filter:            "box",
+		MinSamples:        16,
+		NoiseThreshold:    0,
+		SnapshotEvery:     10,
+		CheckpointEvery:   10,
+		Projection:        "perspective",
+		Pos:               vec3.Vec3{X: 7, Y: 7, Z: 7},
+		LookAt:            vec3.Vec3{X: 1, Y: 0.2, Z: 1},
+		Up:                vec3.Vec3{Y: 1},
+		FOV:               40,
+		Aperture:          0.1,
+		AOVFormat:         "png",
+		Denoise:           "none",
+		DenoiseIterations: DefaultDenoiseOptions().Iterations,
+		Views:             1,
+		Interocular:       0.065,
+		Rig:               "parallel",
+		Layout:            "side-by-side",
+	}
+}
+
+// vecFlag parses a vector given as "x,y,z"
+type vecFlag struct {
+	v *vec3.Vec3
+}
+
+func (f vecFlag) String() string {
+	if f.v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%g,%g,%g", f.v.X, f.v.Y, f.v.Z)
+}
+
+func (f vecFlag) Set(s string) error {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return errors.New("expect x,y,z")
+	}
+	var xyz [3]float64
+	for i, p := range parts {
+		var err error
+		if xyz[i], err = strconv.ParseFloat(strings.TrimSpace(p), 64); err != nil {
+			return fmt.Errorf("expect x,y,z: %v", err)
+		}
+	}
+	*f.v = vec3.Vec3{X: xyz[0], Y: xyz[1], Z: xyz[2]}
+	return nil
+}
+
+// FlagSet binds the render flags onto o, the usage describes the render
+// command and can be replaced by commands reusing the flags
+func (o *Options) FlagSet(name string, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	fs.IntVar(&o.Width, "width", o.Width, "image width in pixels")
+	fs.IntVar(&o.Height, "height", o.Height, "image height in pixels")
+	fs.IntVar(&o.Samples, "spp", o.Samples, "samples per pixel, the cap when sampling adaptively")
+	fs.IntVar(&o.MaxDepth, "max-depth", o.MaxDepth, "maximum number of bounces per path")
+	fs.Float64Var(&o.TMin, "t-min", o.TMin, "minimum hit distance, avoids self intersection")
+	fs.Int64Var(&o.Seed, "seed", o.Seed, "seed of the sampling patterns")
+	fs.IntVar(&o.Threads, "threads", o.Threads, "number of render workers, 0 uses every CPU")
+	fs.StringVar(&o.Pattern, "sampler", o.Pattern,
+		"pixel sampling pattern: "+strings.Join(sampling.Names, ", "))
+	fs.StringVar(&o.Filter, "filter", o.Filter,
+		"reconstruction filter: "+strings.Join(FilterNames, ", "))
+	fs.Float64Var(&o.FilterRadius, "filter-radius", o.FilterRadius,
+		"filter radius in pixels, 0 picks the filter's default")
+	fs.IntVar(&o.MinSamples, "min-spp", o.MinSamples, "samples per pixel before adaptive sampling may stop")
+	fs.Float64Var(&o.NoiseThreshold, "noise", o.NoiseThreshold,
+		"relative noise threshold of adaptive sampling, 0 always takes -spp samples")
+	fs.StringVar(&o.Heatmap, "heatmap", o.Heatmap, "write the samples spent per pixel to this image")
+	fs.StringVar(&o.AOVs, "aovs", o.AOVs,
+		"first hit buffers to write beside the image, comma separated or all: "+strings.Join(AOVNames, ", "))
+	fs.StringVar(&o.AOVFormat, "aov-format", o.AOVFormat,
+		"png writes each buffer to out-<name>.png, exr writes them with the image to out.exr")
+	fs.StringVar(&o.Denoise, "denoise", o.Denoise,
+		"denoiser guided by the first hit buffers: "+strings.Join(DenoiseNames, ", "))
+	fs.IntVar(&o.DenoiseIterations, "denoise-iterations", o.DenoiseIterations,
+		"passes of the atrous denoiser, each doubling its reach")
+
+	fs.BoolVar(&o.Progressive, "progressive", o.Progressive,
+		"render one sample per pixel per pass, writing the output as it refines")
+	fs.IntVar(&o.SnapshotEvery, "snapshot-every", o.SnapshotEvery, "passes between progressive snapshots")
+	fs.DurationVar(&o.SnapshotPeriod, "snapshot-period", o.SnapshotPeriod,
+		"also write a snapshot once this long has passed since the last, e.g. 30s")
+	fs.DurationVar(&o.TimeBudget, "time-budget", o.TimeBudget, "stop progressive rendering after this long, e.g. 10m")
+	fs.StringVar(&o.Checkpoint, "checkpoint", o.Checkpoint,
+		"write a checkpoint to this file periodically and on interrupt, implies -progressive")
+	fs.IntVar(&o.CheckpointEvery, "checkpoint-every", o.CheckpointEvery, "passes between checkpoints")
+	fs.DurationVar(&o.CheckpointPeriod, "checkpoint-period", o.CheckpointPeriod,
+		"also write a checkpoint once this long has passed since the last, e.g. 5m")
+	fs.BoolVar(&o.Resume, "resume", o.Resume, "continue the render saved in -checkpoint")
+
+	fs.StringVar(&o.Projection, "projection", o.Projection,
+		"camera projection: "+strings.Join(ProjectionNames, ", "))
+	fs.Var(vecFlag{&o.Pos}, "camera-pos", "camera position as x,y,z")
+	fs.Var(vecFlag{&o.LookAt}, "look-at", "point the camera looks at as x,y,z")
+	fs.Var(vecFlag{&o.Up}, "up", "camera up direction as x,y,z")
+	fs.Float64Var(&o.FOV, "fov", o.FOV,
+		"vertical field of view in degrees, of the image circle for fisheyes")
+	fs.Float64Var(&o.OrthoHeight, "ortho-height", o.OrthoHeight,
+		"height seen by the orthographic camera, 0 matches -fov at the -look-at distance")
+	fs.Float64Var(&o.Aperture, "aperture", o.Aperture, "lens diameter of the perspective camera, larger for stronger defocus")
+	fs.Float64Var(&o.FocusDist, "focus-dist", o.FocusDist, "distance to the focal plane, 0 focuses on -look-at")
+	fs.Float64Var(&o.FocalLength, "focal-length", o.FocalLength,
+		"focal length in mm on a full frame sensor, replaces -fov when not 0")
+	fs.Float64Var(&o.FNumber, "f-number", o.FNumber,
+		"aperture as an f-number of the focal length, replaces -aperture when not 0")
+	fs.StringVar(&o.Autofocus, "autofocus", o.Autofocus,
+		"focus on the surface seen through pixel x,y (from the top left corner) or center")
+	fs.IntVar(&o.ApertureBlades, "aperture-blades", o.ApertureBlades,
+		"number of diaphragm blades giving a polygonal bokeh, 0 for a round one")
+	fs.Float64Var(&o.ApertureRotation, "aperture-rotation", o.ApertureRotation,
+		"rotation of the polygonal aperture in degrees")
+	fs.StringVar(&o.ApertureMask, "aperture-mask", o.ApertureMask,
+		"grayscale image giving the shape of the aperture, white is open")
+
+	fs.IntVar(&o.Views, "views", o.Views, "number of views of the camera rig, 2 for stereo")
+	fs.Float64Var(&o.Interocular, "interocular", o.Interocular, "distance between neighbour views of the rig")
+	fs.Float64Var(&o.Convergence, "convergence", o.Convergence,
+		"distance where the views converge, 0 converges on -look-at")
+	fs.StringVar(&o.Rig, "rig", o.Rig, "camera rig: "+strings.Join(RigNames, ", "))
+	fs.StringVar(&o.Layout, "layout", o.Layout,
+		"how views are written: "+strings.Join(LayoutNames, ", ")+" (suffixing the output name)")
+
+	fs.StringVar(&o.CPUProfile, "cpuprofile", o.CPUProfile,
+		"write a CPU profile to this file, samples are labelled with the phase: parse, build, render, denoise, encode")
+	fs.StringVar(&o.MemProfile, "memprofile", o.MemProfile, "write a profile of the memory in use at the end to this file")
+	fs.StringVar(&o.AllocProfile, "allocprofile", o.AllocProfile, "write a profile of every allocation to this file")
+	fs.StringVar(&o.Trace, "trace", o.Trace, "write an execution trace to this file, the phases are regions of it")
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] <scene file> <output file>\n\n", name)
+		fmt.Fprintln(fs.Output(), "Renders the scene into a PNG image. Flags:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// ParseArgs parses the command line arguments following the program name,
+// usage and errors are reported on output. It returns flag.ErrHelp when
+// help was requested.
+func ParseArgs(name string, args []string, output io.Writer) (*Options, error) {
+	o := DefaultOptions()
+	fs := o.FlagSet(name, output)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return nil, fmt.Errorf("expect a scene file and an output file, got %d arguments", fs.NArg())
+	}
+	o.Scene, o.Output = fs.Arg(0), fs.Arg(1)
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Validate checks every setting is within range
+func (o *Options) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+	check(o.Width > 0 && o.Height > 0, "-width and -height must be positive, got %dx%d", o.Width, o.Height)
+	check(o.Samples > 0, "-spp must be positive, got %d", o.Samples)
+	check(o.MaxDepth >= 0, "-max-depth must not be negative, got %d", o.MaxDepth)
+	check(o.TMin >= 0, "-t-min must not be negative, got %g", o.TMin)
+	check(o.Threads >= 0, "-threads must not be negative, got %d", o.Threads)
+	if _, err := sampling.ByName(o.Pattern, o.Samples, o.Seed); err != nil {
+		check(false, "-sampler: %v", err)
+	}
+	if _, err := NewFilter(o.Filter, o.FilterRadius); err != nil {
+		check(false, "-filter: %v", err)
+	}
+	check(o.MinSamples > 0, "-min-spp must be positive, got %d", o.MinSamples)
+	check(o.NoiseThreshold >= 0, "-noise must not be negative, got %g", o.NoiseThreshold)
+	check(o.SnapshotEvery >= 0, "-snapshot-every must not be negative, got %d", o.SnapshotEvery)
+	check(o.CheckpointEvery >= 0, "-checkpoint-every must not be negative, got %d", o.CheckpointEvery)
+	check(o.SnapshotPeriod >= 0, "-snapshot-period must not be negative, got %v", o.SnapshotPeriod)
+	check(o.CheckpointPeriod >= 0, "-checkpoint-period must not be negative, got %v", o.CheckpointPeriod)
+	check(!o.Resume || o.Checkpoint != "", "-resume needs -checkpoint")
+	check(o.FocalLength == 0 || o.Projection == "perspective", "-focal-length needs the perspective projection")
+	switch o.Projection {
+	case "perspective", "orthographic":
+		check(o.FOV > 0 && o.FOV < 180, "-fov must be within (0, 180) degrees, got %g", o.FOV)
+	case "fisheye", "fisheye-equisolid":
+		check(o.FOV > 0 && o.FOV <= 360, "-fov must be within (0, 360] degrees for a fisheye, got %g", o.FOV)
+	case "equirect":
+	default:
+		check(false, "-projection: unknown projection %q, expect one of %s",
+			o.Projection, strings.Join(ProjectionNames, ", "))
+	}
+	check(o.OrthoHeight >= 0, "-ortho-height must not be negative, got %g", o.OrthoHeight)
+	check(o.Aperture >= 0, "-aperture must not be negative, got %g", o.Aperture)
+	check(o.FocusDist >= 0, "-focus-dist must not be negative, got %g", o.FocusDist)
+	check(o.FocalLength >= 0, "-focal-length must not be negative, got %g", o.FocalLength)
+	check(o.FNumber >= 0, "-f-number must not be negative, got %g", o.FNumber)
+	check(o.ApertureBlades == 0 || o.ApertureBlades >= 3, "-aperture-blades must be 0 or at least 3, got %d", o.ApertureBlades)
+	check(o.ApertureBlades == 0 || o.ApertureMask == "", "-aperture-blades and -aperture-mask exclude each other")
+	check(o.ApertureBlades == 0 && o.ApertureMask == "" || o.Projection == "perspective",
+		"-aperture-blades and -aperture-mask need the perspective projection")
+	if o.Autofocus != "" {
+		_, err := o.autofocusPixel()
+		check(err == nil, "-autofocus: %v", err)
+		check(o.Projection == "perspective", "-autofocus needs the perspective projection")
+	}
+	check(o.Pos.Sub(&o.LookAt).Length() > 0, "-camera-pos and -look-at must differ")
+	check(o.Up.Cross(o.Pos.Sub(&o.LookAt)).Length() > 0, "-up must not be parallel to the view direction")
+	if _, err := o.AOVList(); err != nil {
+		check(false, "-aovs: %v", err)
+	}
+	check(o.AOVFormat == "png" || o.AOVFormat == "exr", "-aov-format must be png or exr, got %q", o.AOVFormat)
+	check(o.AOVs == "" || o.Views == 1, "-aovs cannot be combined with -views")
+	check(o.AOVs == "" || !o.Resume, "-aovs cannot be combined with -resume")
+	check(contains(DenoiseNames, o.Denoise), "-denoise: unknown denoiser %q, expect one of %s",
+		o.Denoise, strings.Join(DenoiseNames, ", "))
+	check(o.DenoiseIterations > 0, "-denoise-iterations must be positive, got %d", o.DenoiseIterations)
+	check(o.Denoise == "none" || !o.Resume, "-denoise cannot be combined with -resume")
+	check(o.Views > 0, "-views must be positive, got %d", o.Views)
+	check(o.Interocular >= 0, "-interocular must not be negative, got %g", o.Interocular)
+	check(o.Convergence >= 0, "-convergence must not be negative, got %g", o.Convergence)
+	check(contains(RigNames, o.Rig), "-rig: unknown rig %q, expect one of %s", o.Rig, strings.Join(RigNames, ", "))
+	check(contains(LayoutNames, o.Layout), "-layout: unknown layout %q, expect one of %s",
+		o.Layout, strings.Join(LayoutNames, ", "))
+	check(o.Views == 1 || !o.Progressive && o.Checkpoint == "",
+		"-views cannot be combined with -progressive or -checkpoint")
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// NewSampler creates a sampler configured with every option but the world
+func (o *Options) NewSampler() (*Sampler, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	s := NewSampler(o.Width, o.Height, o.Samples, o.MaxDepth, o.TMin, int(o.Seed))
+
+	pat, err := sampling.ByName(o.Pattern, o.Samples, o.Seed)
+	if err != nil {
+		return nil, err
+	}
+	s.SetPattern(pat)
+	f, err := NewFilter(o.Filter, o.FilterRadius)
+	if err != nil {
+		return nil, err
+	}
+	s.SetFilter(f)
+	if o.NoiseThreshold > 0 {
+		s.SetAdaptive(o.MinSamples, o.NoiseThreshold)
+	}
+	switch {
+	case o.Threads == 0:
+		s.SetParallel(runtime.NumCPU())
+	case o.Threads > 1:
+		s.SetParallel(o.Threads)
+	}
+	cam, err := o.NewCamera()
+	if err != nil {
+		return nil, err
+	}
+	s.SetCamera(cam)
+	aovs, err := o.AOVList()
+	if err != nil {
+		return nil, err
+	}
+	if len(aovs) > 0 || o.Denoise != "none" {
+		// the denoiser is guided by the buffers even when none is written
+		if err := s.EnableAOVs(aovs...); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// DenoiseOptions returns the settings of the denoiser
+func (o *Options) DenoiseOptions() DenoiseOptions {
+	d := DefaultDenoiseOptions()
+	d.Iterations = o.DenoiseIterations
+	return d
+}
+
+// AOVList splits AOVs into buffer names, all standing for every one
+func (o *Options) AOVList() ([]string, error) {
+	switch o.AOVs {
+	case "":
+		return nil, nil
+	case "all":
+		return AOVNames, nil
+	}
+	names := strings.Split(o.AOVs, ",")
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+		if !contains(AOVNames, names[i]) {
+			return nil, fmt.Errorf("unknown AOV %q, expect all or some of %s", names[i], strings.Join(AOVNames, ", "))
+		}
+	}
+	return names, nil
+}
+
+// ProjectionNames lists the camera projections of -projection, fisheye
+// maps angles equidistantly
+var ProjectionNames = []string{"perspective", "orthographic", "fisheye", "fisheye-equisolid", "equirect"}
+
+// NewCamera creates the camera of the options, which must be valid, only
+// loading the aperture mask may fail
+func (o *Options) NewCamera() (ray.Camera, error) {
+	aspect := float64(o.Width) / float64(o.Height)
+	switch o.Projection {
+	case "orthographic":
+		height := o.OrthoHeight
+		if height == 0 {
+			height = 2 * math.Tan(o.FOV*math.Pi/360) * o.Pos.Sub(&o.LookAt).Length()
+		}
+		return ray.NewOrthographicCamera(height, aspect, o.Pos, o.LookAt, o.Up), nil
+	case "fisheye":
+		return ray.NewFisheyeCamera(o.FOV, aspect, ray.Equidistant, o.Pos, o.LookAt, o.Up), nil
+	case "fisheye-equisolid":
+		return ray.NewFisheyeCamera(o.FOV, aspect, ray.Equisolid, o.Pos, o.LookAt, o.Up), nil
+	case "equirect":
+		return ray.NewEquirectCamera(o.Pos, o.LookAt, o.Up), nil
+	}
+	fov, aperture := o.lens()
+	cam := ray.NewPerspectiveCamera(fov, aspect, aperture, o.FocusDist, o.Pos, o.LookAt, o.Up)
+	if o.viewShift != 0 {
+		cam.Shift(o.viewShift, 0)
+	}
+	switch {
+	case o.ApertureBlades > 0:
+		cam.SetAperture(ray.PolygonalAperture{
+			Blades:   o.ApertureBlades,
+			Rotation: o.ApertureRotation * math.Pi / 180,
+		})
+	case o.ApertureMask != "":
+		mask, err := loadImage(o.ApertureMask)
+		if err != nil {
+			return nil, err
+		}
+		a, err := ray.NewMaskAperture(mask)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", o.ApertureMask, err)
+		}
+		cam.SetAperture(a)
+	}
+	return cam, nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lens returns the vertical field of view and the lens diameter of the
+// perspective camera, from the focal length and f-number when given
+func (o *Options) lens() (fov, aperture float64) {
+	fov, aperture = o.FOV, o.Aperture
+	if o.FocalLength > 0 {
+		fov = ray.FOVFromFocalLength(o.FocalLength)
+	}
+	if o.FNumber > 0 {
+		aperture = ray.ApertureFromFNumber(ray.FocalLengthFromFOV(fov), o.FNumber)
+	}
+	return fov, aperture
+}
+
+// ProgressiveOptions returns the progressive settings of o
+func (o *Options) ProgressiveOptions() ProgressiveOptions {
+	return ProgressiveOptions{
+		SnapshotPath:     o.Output,
+		SnapshotEvery:    o.SnapshotEvery,
+		SnapshotPeriod:   o.SnapshotPeriod,
+		CheckpointPath:   o.Checkpoint,
+		CheckpointEvery:  o.CheckpointEvery,
+		CheckpointPeriod: o.CheckpointPeriod,
+		TimeBudget:       o.TimeBudget,
+		TargetNoise:      o.NoiseThreshold,
+		MinSamples:       o.MinSamples,
+	}
+}
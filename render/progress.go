@@ -0,0 +1,140 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Progress is a snapshot of a running render handed to the progress
+// observer after every tile
+type Progress struct {
+	// Pixels of TotalPixels are done in pass Pass of TotalPasses, a plain
+	// Render is a single pass
+	Pixels, TotalPixels int
+	Pass, TotalPasses   int
+	// Samples counts the camera samples traced so far
+	Samples int64
+	Elapsed time.Duration
+	// ETA extrapolates the rate since the render was started, it is zero
+	// until there is something to extrapolate from
+	ETA time.Duration
+}
+
+// Fraction returns the completed fraction of the whole render
+func (p Progress) Fraction() float64 {
+	pass := float64(p.Pixels) / float64(p.TotalPixels)
+	return (float64(p.Pass) + pass) / float64(p.TotalPasses)
+}
+
+// RayStats counts the rays traced by a sampler. Every camera sample is one
+// primary ray, every bounce one secondary ray. Materials are not lit
+// explicitly so no shadow rays are traced yet.
+type RayStats struct {
+	Primary, Secondary, Shadow int64
+	Elapsed                    time.Duration
+}
+
+// Total returns the number of rays of all kinds
+func (r RayStats) Total() int64 {
+	return r.Primary + r.Secondary + r.Shadow
+}
+
+// MraysPerSec returns the throughput in millions of rays per second
+func (r RayStats) MraysPerSec() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Total()) / 1e6 / r.Elapsed.Seconds()
+}
+
+func (r RayStats) String() string {
+	return fmt.Sprintf("%d primary, %d secondary, %d shadow rays in %v, %.2f Mrays/s",
+		r.Primary, r.Secondary, r.Shadow, r.Elapsed.Round(time.Millisecond), r.MraysPerSec())
+}
+
+// add accumulates a tile's counters into r, it is safe to call concurrently
+func (r *RayStats) add(tile *RayStats) {
+	atomic.AddInt64(&r.Primary, tile.Primary)
+	atomic.AddInt64(&r.Secondary, tile.Secondary)
+	atomic.AddInt64(&r.Shadow, tile.Shadow)
+}
+
+// tracker follows the progress of the running render
+type tracker struct {
+	observer      func(Progress)
+	start         time.Time
+	startFraction float64
+	pass, total   int
+}
+
+// SetProgress registers fn to be called after every tile. Calls come from
+// a single goroutine at a time, but not necessarily the caller's one.
+func (s *Sampler) SetProgress(fn func(Progress)) {
+	s.track.observer = fn
+}
+
+// RayStats returns the rays traced by all renders of this sampler so far
+func (s *Sampler) RayStats() RayStats {
+	return RayStats{
+		Primary:   atomic.LoadInt64(&s.rays.Primary),
+		Secondary: atomic.LoadInt64(&s.rays.Secondary),
+		Shadow:    atomic.LoadInt64(&s.rays.Shadow),
+		Elapsed:   s.rays.Elapsed,
+	}
+}
+
+// beginRender starts timing a render of totalPasses passes, pass of them
+// being already done
+func (s *Sampler) beginRender(pass, totalPasses int) {
+	s.track.start = time.Now()
+	s.track.pass, s.track.total = pass, totalPasses
+	s.track.startFraction = float64(pass) / float64(totalPasses)
+}
+
+// endRender adds the time spent since beginRender to the ray statistics
+func (s *Sampler) endRender() {
+	s.rays.Elapsed += time.Since(s.track.start)
+}
+
+// report hands the progress of the current pass to the observer
+func (s *Sampler) report(pixels int) {
+	if s.track.observer == nil {
+		return
+	}
+	p := Progress{
+		Pixels:      pixels,
+		TotalPixels: s.width * s.height,
+		Pass:        s.track.pass,
+		TotalPasses: s.track.total,
+		Samples:     atomic.LoadInt64(&s.rays.Primary),
+		Elapsed:     time.Since(s.track.start),
+	}
+	if done := p.Fraction() - s.track.startFraction; done > 0 {
+		rate := done / p.Elapsed.Seconds()
+		p.ETA = time.Duration((1 - p.Fraction()) / rate * float64(time.Second))
+	}
+	s.track.observer(p)
+}
+
+// NewProgressBar returns a progress observer drawing a single line bar on
+// w, redrawn at most every 100ms; the caller ends the line once done
+func NewProgressBar(w io.Writer) func(Progress) {
+	const width = 30
+	var last time.Time
+	return func(p Progress) {
+		f := p.Fraction()
+		finished := p.Pass+1 == p.TotalPasses && p.Pixels == p.TotalPixels
+		if !finished && time.Since(last) < 100*time.Millisecond {
+			return
+		}
+		last = time.Now()
+
+		filled := int(f * width)
+		fmt.Fprintf(w, "\r[%s%s] %5.1f%% %d samples, %v elapsed, ETA %v ",
+			strings.Repeat("#", filled), strings.Repeat("-", width-filled), 100*f,
+			p.Samples, p.Elapsed.Round(time.Second), p.ETA.Round(time.Second))
+	}
+}
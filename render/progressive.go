@@ -0,0 +1,101 @@
+package render
+
+import (
+	"context"
+	"image"
+	"time"
+)
+
+// ProgressiveOptions configures RenderProgressive, zero values disable the
+// corresponding snapshot trigger or stopping criterion
+type ProgressiveOptions struct {
+	// SnapshotPath receives the intermediate image
+	SnapshotPath string
+	// SnapshotEvery writes a snapshot after this many passes
+	SnapshotEvery int
+	// SnapshotPeriod writes a snapshot once this much time has passed
+	SnapshotPeriod time.Duration
+	// CheckpointPath receives the checkpoint, see SaveCheckpoint. It is
+	// also written when the render stops for any reason.
+	CheckpointPath string
+	// CheckpointEvery and CheckpointPeriod trigger checkpoints the same
+	// way snapshots are triggered
+	CheckpointEvery  int
+	CheckpointPeriod time.Duration
+	// TimeBudget stops after the pass that exceeds it
+	TimeBudget time.Duration
+	// TargetNoise stops once every pixel has at least MinSamples and a
+	// relative 95% confidence interval below it
+	TargetNoise float64
+	MinSamples  int
+}
+
+// periodic tells when a pass-or-time based trigger is due
+type periodic struct {
+	every  int
+	period time.Duration
+	last   time.Time
+}
+
+func (p *periodic) due(pass int) bool {
+	byCount := p.every > 0 && pass%p.every == 0
+	byTime := p.period > 0 && time.Since(p.last) >= p.period
+	return byCount || byTime
+}
+
+// RenderProgressive renders one sample per pixel per pass over the whole
+// image, up to finess passes, continuing after the passes restored by
+// LoadCheckpoint. Whatever has been accumulated is resolved into ImgOut
+// when it stops; when ctx is cancelled it comes with an IncompleteError.
+func (s *Sampler) RenderProgressive(ctx context.Context, opts ProgressiveOptions) (*image.RGBA64, error) {
+	start := time.Now()
+	snapshot := periodic{opts.SnapshotEvery, opts.SnapshotPeriod, start}
+	ckpt := periodic{opts.CheckpointEvery, opts.CheckpointPeriod, start}
+	s.beginRender(s.passes, s.finess)
+	defer s.endRender()
+	// passes of the last checkpoint, every way out leaves a current one
+	saved := -1
+
+	for s.passes < s.finess {
+		s.track.pass = s.passes
+		completed := s.renderPass(ctx, s.passes+1)
+		if err := ctx.Err(); err != nil && completed < s.width*s.height {
+			s.resolve()
+			if opts.CheckpointPath != "" {
+				if err := s.SaveCheckpoint(opts.CheckpointPath); err != nil {
+					return s.ImgOut, err
+				}
+			}
+			return s.ImgOut, s.incomplete(err, s.passes, s.finess, completed)
+		}
+		s.passes++
+
+		if opts.SnapshotPath != "" && snapshot.due(s.passes) {
+			s.resolve()
+			if err := s.Save(opts.SnapshotPath); err != nil {
+				return s.ImgOut, err
+			}
+			snapshot.last = time.Now()
+		}
+		if opts.CheckpointPath != "" && ckpt.due(s.passes) {
+			if err := s.SaveCheckpoint(opts.CheckpointPath); err != nil {
+				return s.ImgOut, err
+			}
+			ckpt.last, saved = time.Now(), s.passes
+		}
+
+		if opts.TimeBudget > 0 && time.Since(start) >= opts.TimeBudget {
+			break
+		}
+		if opts.TargetNoise > 0 && s.passes >= opts.MinSamples && s.film.unconverged(opts.TargetNoise) == 0 {
+			break
+		}
+	}
+	s.resolve()
+	if opts.CheckpointPath != "" && saved != s.passes {
+		if err := s.SaveCheckpoint(opts.CheckpointPath); err != nil {
+			return s.ImgOut, err
+		}
+	}
+	return s.ImgOut, nil
+}
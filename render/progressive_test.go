@@ -0,0 +1,47 @@
+package render
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// TestCheckpointOnEveryStop checks the checkpoint holds every rendered
+// pass whichever way the progressive render stops, even when CheckpointEvery
+// never came due
+func TestCheckpointOnEveryStop(t *testing.T) {
+	cases := []struct {
+		name string
+		opts ProgressiveOptions
+	}{
+		{"finished", ProgressiveOptions{CheckpointEvery: 100}},
+		{"noise target", ProgressiveOptions{CheckpointEvery: 100, TargetNoise: 1e9, MinSamples: 2}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			o := DefaultOptions()
+			o.Width, o.Height, o.Samples = 8, 4, 5
+			c.opts.CheckpointPath = filepath.Join(t.TempDir(), "render.ckpt")
+			s, err := o.NewSampler()
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.SetWorldObj(testWorld())
+			if _, err := s.RenderProgressive(context.Background(), c.opts); err != nil {
+				t.Fatal(err)
+			}
+
+			resumed, err := o.NewSampler()
+			if err != nil {
+				t.Fatal(err)
+			}
+			resumed.SetWorldObj(testWorld())
+			if err := resumed.LoadCheckpoint(c.opts.CheckpointPath); err != nil {
+				t.Fatal(err)
+			}
+			if resumed.Passes() != s.Passes() {
+				t.Errorf("checkpoint holds %d passes, the render stopped after %d", resumed.Passes(), s.Passes())
+			}
+		})
+	}
+}
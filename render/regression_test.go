@@ -0,0 +1,82 @@
+package render
+
+import (
+	"context"
+	"flag"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Oaklight/Rayerson/imgcmp"
+)
+
+var update = flag.Bool("update", false, "rewrite the reference renders of testdata")
+
+// Tolerances of the regression renders, loose enough for floating point
+// differences between platforms, tight enough for any visible change
+const (
+	minPSNR = 40
+	minSSIM = 0.99
+	maxFLIP = 0.02
+)
+
+// renderScene renders a small version of a scene of test/ with a fixed seed
+func renderScene(t *testing.T, scene string) image.Image {
+	o := DefaultOptions()
+	o.Width, o.Height = 80, 40
+	o.Samples, o.NoiseThreshold = 16, 0
+	o.Threads = 0
+	o.Seed = 42
+	w, err := LoadScene(scene)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := o.NewSampler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetWorldObj(w)
+	if _, err := s.Render(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s.ImgOut
+}
+
+// TestRegression compares renders of the test scenes with the references
+// of testdata, go test ./render -run Regression -args -update rewrites them
+func TestRegression(t *testing.T) {
+	scenes, err := filepath.Glob("../test/*.csv")
+	if err != nil || len(scenes) == 0 {
+		t.Fatalf("no test scene: %v", err)
+	}
+	for _, scene := range scenes {
+		name := strings.TrimSuffix(filepath.Base(scene), ".csv")
+		t.Run(name, func(t *testing.T) {
+			got := renderScene(t, scene)
+			reference := filepath.Join("testdata", name+".png")
+			if *update {
+				if err := SaveImage(reference, got); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := imgcmp.Load(reference)
+			if err != nil {
+				t.Fatalf("%v, run go test ./render -run Regression -args -update to create it", err)
+			}
+			r, err := imgcmp.Compare(got, want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.PSNR < minPSNR || r.SSIM < minSSIM || r.FLIP > maxFLIP {
+				t.Errorf("%s, expect PSNR >= %g, SSIM >= %g and FLIP <= %g", r, float64(minPSNR), minSSIM, maxFLIP)
+				heatmap := filepath.Join(os.TempDir(), name+"-flip.png")
+				if flip, err := imgcmp.FLIP(got, want, imgcmp.DefaultPPD); err == nil && SaveImage(heatmap, flip.Heatmap()) == nil {
+					t.Logf("error heatmap written to %s", heatmap)
+				}
+			}
+		})
+	}
+}
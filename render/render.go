@@ -0,0 +1,158 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sync"
+
+	"github.com/Oaklight/Rayerson/sampling"
+)
+
+const bufferSize = 2
+
+// tileSize is the edge length of the square tiles handed to workers
+const tileSize = 16
+
+// IncompleteError is returned along with the partial image when a render
+// is cancelled, it tells how much of the render was completed
+type IncompleteError struct {
+	Err error
+	// Passes of TotalPasses were fully rendered, Pixels of TotalPixels
+	// were then completed in the interrupted pass
+	Passes, TotalPasses int
+	Pixels, TotalPixels int
+}
+
+// Completed returns the completed fraction of the whole render
+func (e *IncompleteError) Completed() float64 {
+	pass := float64(e.Pixels) / float64(e.TotalPixels)
+	return (float64(e.Passes) + pass) / float64(e.TotalPasses)
+}
+
+func (e *IncompleteError) Error() string {
+	return fmt.Sprintf("render incomplete, %.1f%% done (%d/%d passes, %d/%d pixels of the next): %v",
+		100*e.Completed(), e.Passes, e.TotalPasses, e.Pixels, e.TotalPixels, e.Err)
+}
+
+func (e *IncompleteError) Unwrap() error {
+	return e.Err
+}
+
+// tiles splits the image into tileSize squares in scanline order
+func (s *Sampler) tiles() []image.Rectangle {
+	var ts []image.Rectangle
+	for y := 0; y < s.height; y += tileSize {
+		for x := 0; x < s.width; x += tileSize {
+			ts = append(ts, image.Rect(x, y, x+tileSize, y+tileSize).Intersect(s.film.bounds))
+		}
+	}
+	return ts
+}
+
+// Render takes all finess samples of every pixel in a single pass. When ctx
+// is cancelled the pixels done so far are returned with an IncompleteError.
+func (s *Sampler) Render(ctx context.Context) (*image.RGBA64, error) {
+	s.beginRender(0, 1)
+	defer s.endRender()
+
+	completed := s.renderPass(ctx, s.finess)
+	s.resolve()
+	if err := ctx.Err(); err != nil && completed < s.width*s.height {
+		return s.ImgOut, s.incomplete(err, 0, 1, completed)
+	}
+	s.passes = s.finess
+	return s.ImgOut, nil
+}
+
+func (s *Sampler) incomplete(err error, passes, totalPasses, pixels int) error {
+	return &IncompleteError{
+		Err:         err,
+		Passes:      passes,
+		TotalPasses: totalPasses,
+		Pixels:      pixels,
+		TotalPixels: s.width * s.height,
+	}
+}
+
+// renderPass takes every pixel that has not converged yet up to the sample
+// with index last, and returns how many pixels got there
+func (s *Sampler) renderPass(ctx context.Context, last int) int {
+	if !s.isParallel {
+		completed := 0
+		for _, t := range s.tiles() {
+			completed += s.renderTile(ctx, t, s.pattern, last)
+			s.report(completed)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return completed
+	}
+
+	tileStream := make(chan image.Rectangle, bufferSize)
+	go func() {
+		defer close(tileStream)
+		for _, t := range s.tiles() {
+			select {
+			case <-ctx.Done():
+				return
+			case tileStream <- t:
+			}
+		}
+	}()
+
+	workers := make([]<-chan int, s.nThread)
+	for i := range workers {
+		workers[i] = s.worker(ctx, tileStream, s.pattern.Clone(), last)
+	}
+
+	completed := 0
+	for p := range collector(workers...) {
+		completed += p
+		s.report(completed)
+	}
+	return completed
+}
+
+// worker renders tiles until the stream runs dry or ctx is cancelled, the
+// number of pixels finished in each tile is reported on the returned channel
+func (s *Sampler) worker(ctx context.Context, tileStream <-chan image.Rectangle, pat sampling.Pattern, last int) <-chan int {
+	progressStream := make(chan int, 10)
+	go func() {
+		defer close(progressStream)
+		for t := range tileStream {
+			progressStream <- s.renderTile(ctx, t, pat, last)
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+	return progressStream
+}
+
+// collector multiplexes the progress of all workers, it is closed once
+// every worker is done
+func collector(progressStream ...<-chan int) <-chan int {
+	var wg sync.WaitGroup
+	multiplexedStream := make(chan int, 100)
+
+	multiplex := func(px <-chan int) {
+		defer wg.Done()
+		for p := range px {
+			multiplexedStream <- p
+		}
+	}
+
+	wg.Add(len(progressStream))
+	for _, c := range progressStream {
+		go multiplex(c)
+	}
+
+	go func() {
+		wg.Wait()
+		close(multiplexedStream)
+	}()
+
+	return multiplexedStream
+}
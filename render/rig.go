@@ -0,0 +1,101 @@
+package render
+
+import (
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RigNames lists the camera rigs of -rig: parallel keeps the optical axes
+// parallel and shifts the images so that the convergence plane has no
+// parallax, toe-in turns every camera towards the convergence point
+var RigNames = []string{"parallel", "toe-in"}
+
+// LayoutNames lists how -layout writes the views of a rig
+var LayoutNames = []string{"side-by-side", "separate"}
+
+// ViewOptions returns the options of every view of the rig, ordered from
+// left to right and spaced by Interocular along the camera right axis. The
+// views share everything but the camera, a single view is o itself.
+func (o *Options) ViewOptions() []*Options {
+	if o.Views <= 1 {
+		return []*Options{o}
+	}
+	forward := o.LookAt.Sub(&o.Pos)
+	convergence := o.Convergence
+	if convergence == 0 {
+		convergence = forward.Length()
+	}
+	forward = forward.Normalize()
+	right := forward.Cross(&o.Up).Normalize()
+	fov, _ := o.lens()
+	halfWidth := float64(o.Width) / float64(o.Height) * math.Tan(fov*math.Pi/360)
+
+	views := make([]*Options, o.Views)
+	for i := range views {
+		offset := (float64(i) - float64(o.Views-1)/2) * o.Interocular
+		view := *o
+		view.Views = 1
+		view.Pos = *o.Pos.Add(right.MulScalar(offset))
+		switch o.Rig {
+		case "toe-in":
+			view.LookAt = *o.Pos.Add(forward.MulScalar(convergence))
+		default:
+			view.LookAt = *o.LookAt.Add(right.MulScalar(offset))
+			if o.Projection == "perspective" {
+				view.viewShift = -offset / (2 * halfWidth * convergence)
+			}
+		}
+		views[i] = &view
+	}
+	return views
+}
+
+// ViewPath returns where view i of n is written with the separate layout,
+// the views of a pair are suffixed -left and -right, more are numbered
+func ViewPath(filePath string, i, n int) string {
+	if n <= 1 {
+		return filePath
+	}
+	suffix := strconv.Itoa(i)
+	if n == 2 {
+		suffix = [2]string{"left", "right"}[i]
+	}
+	ext := filepath.Ext(filePath)
+	return strings.TrimSuffix(filePath, ext) + "-" + suffix + ext
+}
+
+// SideBySide places the views next to each other, from left to right
+func SideBySide(views []image.Image) *image.RGBA64 {
+	var width, height int
+	for _, v := range views {
+		width += v.Bounds().Dx()
+		height = maxInt(height, v.Bounds().Dy())
+	}
+	out := image.NewRGBA64(image.Rect(0, 0, width, height))
+	x := 0
+	for _, v := range views {
+		b := v.Bounds()
+		draw.Draw(out, image.Rect(x, 0, x+b.Dx(), b.Dy()), v, b.Min, draw.Src)
+		x += b.Dx()
+	}
+	return out
+}
+
+// SaveImage writes img to filePath as a PNG image
+func SaveImage(filePath string, img image.Image) error {
+	outWriter, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err = png.Encode(outWriter, img); err != nil {
+		outWriter.Close()
+		return err
+	}
+	return outWriter.Close()
+}
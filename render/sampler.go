@@ -0,0 +1,253 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	pm "github.com/Oaklight/Rayerson/primitives"
+	"github.com/Oaklight/Rayerson/ray"
+	"github.com/Oaklight/Rayerson/sampling"
+)
+
+// Sampler performs the color sampling on pixel level
+type Sampler struct {
+	width, height    int
+	finess, maxDepth int
+	isParallel       bool
+	nThread          int
+	tMin, tMax       float64
+	seed             int64
+	ImgOut           *image.RGBA64
+	cam              ray.Camera
+	world            *pm.World
+	env              Environment
+	pattern          sampling.Pattern
+	filter           Filter
+	film             *film
+	filmLock         sync.Mutex
+	// passes counts the sample indices every pixel went through
+	passes int
+	track  tracker
+	rays   RayStats
+	// adaptive sampling stops a pixel once its noise is below threshold
+	adaptive   bool
+	minSamples int
+	threshold  float64
+	// aov keeps the first hits of camera rays when enabled, materials
+	// numbers the materials of the world for it
+	aov       *aovBuffer
+	aovNames  []string
+	materials map[pm.Materials]int
+	// denoised is the beauty filtered by the last Denoise
+	denoised *Layer
+}
+
+// NewSampler creates a new sampler for rendering
+func NewSampler(width, height, finess, maxDepth int, tMin float64, seed ...int) *Sampler {
+	s := Sampler{
+		width:    width,
+		height:   height,
+		finess:   finess,
+		maxDepth: maxDepth,
+		tMin:     tMin,
+		tMax:     math.MaxFloat64,
+		ImgOut:   image.NewRGBA64(image.Rect(0, 0, width, height)),
+		filter:   &BoxFilter{0.5},
+		env:      Sky,
+		film:     newFilm(image.Rect(0, 0, width, height), true),
+	}
+	switch len(seed) {
+	case 0:
+		s.seed = time.Now().UTC().UnixNano()
+	default:
+		s.seed = int64(seed[0])
+	}
+	s.pattern = sampling.NewIndependent(s.seed)
+	return &s
+}
+
+// SetPattern replaces the pixel sampling pattern, each worker renders with
+// its own clone of p
+func (s *Sampler) SetPattern(p sampling.Pattern) {
+	s.pattern = p
+}
+
+// SetFilter replaces the pixel reconstruction filter, the default box
+// filter of radius 0.5 averages the samples inside each pixel
+func (s *Sampler) SetFilter(f Filter) {
+	s.filter = f
+}
+
+// SetAdaptive turns on adaptive sampling: every pixel takes at least
+// minSamples, then keeps sampling only while the 95% confidence interval of
+// its mean exceeds threshold (relative to the mean), up to finess samples
+func (s *Sampler) SetAdaptive(minSamples int, threshold float64) {
+	s.adaptive = true
+	s.minSamples = minSamples
+	s.threshold = threshold
+}
+
+// Seed returns the seed the sampler was created with
+func (s *Sampler) Seed() int64 {
+	return s.seed
+}
+
+// Passes returns how many sample indices every pixel has gone through
+func (s *Sampler) Passes() int {
+	return s.passes
+}
+
+func (s *Sampler) SetParallel(nThread int) {
+	s.isParallel = true
+	s.nThread = nThread
+}
+
+// SetCamera sets the camera the primary rays are cast from
+func (s *Sampler) SetCamera(cam ray.Camera) {
+	s.cam = cam
+}
+
+// SetWorldObj sets up the world of hitable objects
+func (s *Sampler) SetWorldObj(world *pm.World) {
+	s.world = world
+	s.materials = materialIndex(world)
+}
+
+// Save saves the image to the given file
+func (s *Sampler) Save(filePath string) error {
+	return SaveImage(filePath, s.ImgOut)
+}
+
+// SaveHeatmap writes the number of samples spent per pixel as an image,
+// white pixels reached the finess cap
+func (s *Sampler) SaveHeatmap(filePath string) error {
+	outWriter, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer outWriter.Close()
+
+	return png.Encode(outWriter, s.film.heatmap(s.finess))
+}
+
+// loadImage decodes the PNG or JPEG image at filePath
+func loadImage(filePath string) (image.Image, error) {
+	inReader, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer inReader.Close()
+
+	img, _, err := image.Decode(inReader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+	return img, nil
+}
+
+// SamplesTaken returns the total number of camera samples traced so far
+func (s *Sampler) SamplesTaken() int {
+	total := 0
+	for i := range s.film.stats {
+		total += s.film.stats[i].n
+	}
+	return total
+}
+
+func (s *Sampler) color4Ray(r *ray.Ray, depth int, src sampling.Source, rays *RayStats) *ray.Color {
+	if depth == 0 {
+		rays.Primary++
+	} else {
+		rays.Secondary++
+	}
+	if hit := s.world.Hit(r, s.tMin, s.tMax); hit != nil {
+
+		if bounced := hit.Materials.Bounce(r, hit, src); bounced != nil && depth < s.maxDepth {
+			newColor := s.color4Ray(bounced, depth+1, src, rays)
+			return hit.Color().Mul(newColor)
+		}
+		return &ray.Opaque
+	}
+	return s.env(r.Direct)
+}
+
+// SamplePixel yields the color for given coordinate (x, y), samples are
+// splatted straight into the image film so it must not run concurrently
+func (s *Sampler) SamplePixel(x, y int) color.RGBA64 {
+	var rays RayStats
+	s.samplePixel(x, y, s.pattern, s.film, s.finess, &rays)
+	s.rays.add(&rays)
+	rgba64 := s.film.color(x, y).RGBA64()
+	s.ImgOut.SetRGBA64(x, s.height-1-y, rgba64)
+
+	return rgba64
+}
+
+// samplePixel takes the samples of pixel (x, y) from the next one not taken
+// yet up to index last, drawing every sample dimension from pat and
+// splatting them into dst. Resuming after an interruption thus never takes
+// the same sample twice.
+func (s *Sampler) samplePixel(x, y int, pat sampling.Pattern, dst *film, last int, rays *RayStats) {
+	stats := s.film.stat(x, y)
+	// anti-aliasing
+	// refine the color by sampling around each pixel, up to given finess,
+	// the sample count is the index of the next sample
+	for stats.n < last {
+		if s.adaptive && stats.n >= s.minSamples && s.film.converged(x, y, s.threshold) {
+			break
+		}
+		pat.StartPixelSample(x, y, stats.n)
+		jx, jy := pat.Get2D()
+		px, py := float64(x)+jx, float64(y)+jy
+		col := &ray.Opaque
+		if r := s.cam.GetRay(px/float64(s.width), py/float64(s.height), pat); r != nil {
+			if s.aov != nil {
+				s.recordAOVs(x, y, stats.n, r)
+			}
+			col = s.color4Ray(r, 0, pat, rays)
+		}
+		dst.splat(s.filter, px, py, col)
+		s.film.addStats(x, y, col)
+	}
+}
+
+// renderTile samples every pixel of t into a private film, then merges it
+// into the image film, only statistics of pixels inside t are touched.
+// Cancelling ctx stops it between pixels, the number of pixels done is
+// returned.
+func (s *Sampler) renderTile(ctx context.Context, t image.Rectangle, pat sampling.Pattern, last int) int {
+	r := int(math.Ceil(s.filter.Radius()))
+	local := newFilm(t.Inset(-r).Intersect(s.film.bounds), false)
+	var rays RayStats
+	completed := 0
+	for y := t.Min.Y; y < t.Max.Y && ctx.Err() == nil; y++ {
+		for x := t.Min.X; x < t.Max.X && ctx.Err() == nil; x++ {
+			s.samplePixel(x, y, pat, local, last, &rays)
+			completed++
+		}
+	}
+	s.filmLock.Lock()
+	s.film.merge(local)
+	s.filmLock.Unlock()
+	s.rays.add(&rays)
+	return completed
+}
+
+// resolve converts the image film into ImgOut
+func (s *Sampler) resolve() {
+	s.filmLock.Lock()
+	defer s.filmLock.Unlock()
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; x++ {
+			s.ImgOut.SetRGBA64(x, s.height-1-y, s.film.color(x, y).RGBA64())
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package render
+
+import (
+	"time"
+
+	pm "github.com/Oaklight/Rayerson/primitives"
+)
+
+// SceneParser reads the scene at csvPath, an unreadable scene gives an
+// empty world. LoadScene reports the errors instead.
+func SceneParser(csvPath string) *pm.World {
+	world, err := LoadScene(csvPath)
+	if err != nil {
+		return &pm.World{}
+	}
+	return world
+}
+
+// RandomScene returns a 'random' scene, seeded from the clock, and writes
+// it to csvPath
+func RandomScene(csvPath string) (*pm.World, error) {
+	opts := DefaultGeneratorOptions()
+	opts.Seed = time.Now().UnixNano()
+	world := GenerateScene(opts)
+	if err := SaveScene(csvPath, world); err != nil {
+		return nil, err
+	}
+	return world, nil
+}
@@ -0,0 +1,392 @@
+package render
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	pm "github.com/Oaklight/Rayerson/primitives"
+	"github.com/Oaklight/Rayerson/ray"
+)
+
+// SceneFormat reads and writes worlds in one file format
+type SceneFormat struct {
+	Name string
+	// Exts are the file extensions of the format, lower case with the dot
+	Exts  []string
+	Read  func(r io.Reader) (*pm.World, error)
+	Write func(w io.Writer, world *pm.World) error
+}
+
+// SceneFormats lists every supported scene format
+var SceneFormats = []SceneFormat{
+	{Name: "csv", Exts: []string{".csv"}, Read: ReadCSV, Write: WriteCSV},
+	{Name: "json", Exts: []string{".json"}, Read: ReadJSON, Write: WriteJSON},
+}
+
+// SceneFormatNames returns the names of SceneFormats
+func SceneFormatNames() []string {
+	names := make([]string, len(SceneFormats))
+	for i, f := range SceneFormats {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// SceneFormatByName returns the format called name
+func SceneFormatByName(name string) (*SceneFormat, error) {
+	for i := range SceneFormats {
+		if SceneFormats[i].Name == strings.ToLower(name) {
+			return &SceneFormats[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown scene format %q, expect one of %s",
+		name, strings.Join(SceneFormatNames(), ", "))
+}
+
+// SceneFormatFor returns the format told by the extension of filePath
+func SceneFormatFor(filePath string) (*SceneFormat, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	for i := range SceneFormats {
+		for _, e := range SceneFormats[i].Exts {
+			if e == ext {
+				return &SceneFormats[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s: unsupported scene format %q", filePath, ext)
+}
+
+// LoadScene reads the world at filePath, in the format told by its
+// extension
+func LoadScene(filePath string) (*pm.World, error) {
+	format, err := SceneFormatFor(filePath)
+	if err != nil {
+		return nil, err
+	}
+	inReader, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer inReader.Close()
+
+	world, err := format.Read(bufio.NewReader(inReader))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+	return world, nil
+}
+
+// SaveScene writes the world to filePath, in the format told by its
+// extension
+func SaveScene(filePath string, world *pm.World) error {
+	format, err := SceneFormatFor(filePath)
+	if err != nil {
+		return err
+	}
+	return format.Save(filePath, world)
+}
+
+// Save writes the world to filePath in format f, whatever its extension
+func (f *SceneFormat) Save(filePath string, world *pm.World) error {
+	outWriter, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	buffered := bufio.NewWriter(outWriter)
+	if err = f.Write(buffered, world); err == nil {
+		err = buffered.Flush()
+	}
+	if cerr := outWriter.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// objectSpec is the plain description of a scene object shared by every
+// format, the world only holds spheres for now
+type objectSpec struct {
+	Type     string       `json:"type"`
+	Center   [3]float64   `json:"center"`
+	Radius   float64      `json:"radius"`
+	Material materialSpec `json:"material"`
+}
+
+// materialSpec describes a material, unused parameters are nil
+type materialSpec struct {
+	Type   string      `json:"type"`
+	Albedo *[3]float64 `json:"albedo,omitempty"`
+	Fuzz   *float64    `json:"fuzz,omitempty"`
+	RefIdx *float64    `json:"refIdx,omitempty"`
+}
+
+// describeObject walks h into its spec
+func describeObject(h pm.Hitable) (objectSpec, error) {
+	s, ok := h.(*pm.Sphere)
+	if !ok {
+		return objectSpec{}, fmt.Errorf("unsupported object %T", h)
+	}
+	m, err := describeMaterial(s.Material)
+	if err != nil {
+		return objectSpec{}, err
+	}
+	return objectSpec{
+		Type:     "Sphere",
+		Center:   [3]float64{s.Center.X, s.Center.Y, s.Center.Z},
+		Radius:   s.Radius,
+		Material: m,
+	}, nil
+}
+
+func describeMaterial(m pm.Materials) (materialSpec, error) {
+	rgb := func(c *ray.Color) *[3]float64 {
+		return &[3]float64{c.R, c.G, c.B}
+	}
+	switch m := m.(type) {
+	case *pm.DiffuseMaterial:
+		return materialSpec{Type: "Diffuse", Albedo: rgb(m.Albedo)}, nil
+	case *pm.MetallicMaterial:
+		fuzz := m.Fuzz
+		return materialSpec{Type: "Metallic", Albedo: rgb(m.Albedo), Fuzz: &fuzz}, nil
+	case *pm.DielectricMaterial:
+		refIdx := m.RefIdx()
+		return materialSpec{Type: "Dielectric", RefIdx: &refIdx}, nil
+	}
+	return materialSpec{}, fmt.Errorf("unsupported material %T", m)
+}
+
+// build creates the object described by o
+func (o objectSpec) build() (pm.Hitable, error) {
+	if !strings.EqualFold(o.Type, "Sphere") {
+		return nil, fmt.Errorf("unknown object type %q", o.Type)
+	}
+	m, err := o.Material.build()
+	if err != nil {
+		return nil, err
+	}
+	return pm.NewSphere(o.Center[0], o.Center[1], o.Center[2], o.Radius, m), nil
+}
+
+func (m materialSpec) build() (pm.Materials, error) {
+	need := func(name string, present bool) error {
+		if !present {
+			return fmt.Errorf("%s material needs %s", m.Type, name)
+		}
+		return nil
+	}
+	albedo := func() *ray.Color {
+		return &ray.Color{R: m.Albedo[0], G: m.Albedo[1], B: m.Albedo[2]}
+	}
+	switch strings.ToLower(m.Type) {
+	case "diffuse":
+		if err := need("albedo", m.Albedo != nil); err != nil {
+			return nil, err
+		}
+		return pm.NewDiffuse(albedo()), nil
+	case "metallic":
+		if err := need("albedo", m.Albedo != nil); err != nil {
+			return nil, err
+		}
+		if err := need("fuzz", m.Fuzz != nil); err != nil {
+			return nil, err
+		}
+		return pm.NewMetallic(albedo(), *m.Fuzz), nil
+	case "dielectric":
+		if err := need("refIdx", m.RefIdx != nil); err != nil {
+			return nil, err
+		}
+		return pm.NewDielectric(*m.RefIdx), nil
+	}
+	return nil, fmt.Errorf("unknown material type %q", m.Type)
+}
+
+// buildWorld creates the world holding the objects of specs
+func buildWorld(specs []objectSpec) (*pm.World, error) {
+	world := pm.World{}
+	for i, o := range specs {
+		h, err := o.build()
+		if err != nil {
+			return nil, fmt.Errorf("object %d: %v", i, err)
+		}
+		world.Add(h)
+	}
+	return &world, nil
+}
+
+// describeWorld walks every object of world
+func describeWorld(world *pm.World) ([]objectSpec, error) {
+	specs := make([]objectSpec, 0, len(*world))
+	for i, each := range *world {
+		if each == nil {
+			continue
+		}
+		o, err := describeObject(each)
+		if err != nil {
+			return nil, fmt.Errorf("object %d: %v", i, err)
+		}
+		specs = append(specs, o)
+	}
+	return specs, nil
+}
+
+// ========================= CSV =========================
+
+// formatFloat writes the shortest representation reading back exactly
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// WriteCSV writes the world as one sphere per line:
+//
+//	x,y,z,radius,Diffuse,r,g,b
+//	x,y,z,radius,Metallic,r,g,b,fuzz
+//	x,y,z,radius,Dielectric,refIdx
+func WriteCSV(w io.Writer, world *pm.World) error {
+	specs, err := describeWorld(world)
+	if err != nil {
+		return err
+	}
+	for _, o := range specs {
+		fields := []string{formatFloat(o.Center[0]), formatFloat(o.Center[1]), formatFloat(o.Center[2]),
+			formatFloat(o.Radius), o.Material.Type}
+		if o.Material.Albedo != nil {
+			for _, c := range o.Material.Albedo {
+				fields = append(fields, formatFloat(c))
+			}
+		}
+		if o.Material.Fuzz != nil {
+			fields = append(fields, formatFloat(*o.Material.Fuzz))
+		}
+		if o.Material.RefIdx != nil {
+			fields = append(fields, formatFloat(*o.Material.RefIdx))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(fields, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadCSV reads the format written by WriteCSV, blank lines and lines
+// starting with # are skipped
+func ReadCSV(r io.Reader) (*pm.World, error) {
+	var specs []objectSpec
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		o, err := parseCSVLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		specs = append(specs, o)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return buildWorld(specs)
+}
+
+func parseCSVLine(text string) (objectSpec, error) {
+	args := strings.Split(text, ",")
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
+	}
+	if len(args) < 5 {
+		return objectSpec{}, fmt.Errorf("expect x,y,z,radius,material,..., got %d fields", len(args))
+	}
+	nums := make([]float64, 0, len(args))
+	for i, a := range args {
+		if i == 4 {
+			continue
+		}
+		f, err := strconv.ParseFloat(a, 64)
+		if err != nil {
+			return objectSpec{}, fmt.Errorf("field %d: %v", i+1, err)
+		}
+		nums = append(nums, f)
+	}
+
+	o := objectSpec{
+		Type:     "Sphere",
+		Center:   [3]float64{nums[0], nums[1], nums[2]},
+		Radius:   nums[3],
+		Material: materialSpec{Type: args[4]},
+	}
+	params := nums[4:]
+	want := map[string]int{"diffuse": 3, "metallic": 4, "dielectric": 1}
+	n, ok := want[strings.ToLower(args[4])]
+	if !ok {
+		return objectSpec{}, fmt.Errorf("unknown material type %q", args[4])
+	}
+	if len(params) != n {
+		return objectSpec{}, fmt.Errorf("%s material needs %d values, got %d", args[4], n, len(params))
+	}
+	switch n {
+	case 1:
+		o.Material.RefIdx = &params[0]
+	case 4:
+		o.Material.Fuzz = &params[3]
+		fallthrough
+	case 3:
+		o.Material.Albedo = &[3]float64{params[0], params[1], params[2]}
+	}
+	return o, nil
+}
+
+// ========================= JSON =========================
+
+// jsonScene is the document written by WriteJSON
+type jsonScene struct {
+	Objects []objectSpec `json:"objects"`
+}
+
+// WriteJSON writes the world as a JSON document holding one object per
+// line
+func WriteJSON(w io.Writer, world *pm.World) error {
+	specs, err := describeWorld(world)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "{\"objects\": ["); err != nil {
+		return err
+	}
+	for i, o := range specs {
+		line, err := json.Marshal(o)
+		if err != nil {
+			return err
+		}
+		sep := ",\n  "
+		if i == 0 {
+			sep = "\n  "
+		}
+		if _, err := fmt.Fprintf(w, "%s%s", sep, line); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, "\n]}\n")
+	return err
+}
+
+// ReadJSON reads the format written by WriteJSON, unknown fields are
+// refused so that typos do not go unnoticed
+func ReadJSON(r io.Reader) (*pm.World, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var doc jsonScene
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after the scene")
+	}
+	return buildWorld(doc.Objects)
+}
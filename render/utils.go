@@ -0,0 +1,16 @@
+package render
+
+// type Context struct {
+// 	nThread int
+// 	done    chan bool
+// 	lock    *sync.Mutex
+// 	turn    *sync.Cond
+// 	fetched *sync.Cond
+// 	wg      *sync.WaitGroup
+// 	job     []*description
+// 	jt      jobType
+// }
+
+// func initWorkerPool(nThread int) {
+
+// }
@@ -0,0 +1,85 @@
+package sampling
+
+import "math/bits"
+
+// hash64 is the splitmix64 finalizer, a cheap and well distributed mixer
+func hash64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// hash32 derives a 32-bit scramble seed from the pixel, dimension and round
+func hash32(pixel uint64, dim, round int) uint32 {
+	return uint32(hash64(pixel ^ hash64(uint64(dim)<<32|uint64(uint32(round)))))
+}
+
+// permute returns the element at position i of a random permutation of
+// [0, l) selected by p, see Kensler, "Correlated Multi-Jittered Sampling"
+func permute(i, l, p uint32) uint32 {
+	w := l - 1
+	w |= w >> 1
+	w |= w >> 2
+	w |= w >> 4
+	w |= w >> 8
+	w |= w >> 16
+	for {
+		i ^= p
+		i *= 0xe170893d
+		i ^= p >> 16
+		i ^= (i & w) >> 4
+		i ^= p >> 8
+		i *= 0x0929eb3f
+		i ^= p >> 23
+		i ^= (i & w) >> 1
+		i *= 1 | p>>27
+		i *= 0x6935fa69
+		i ^= (i & w) >> 11
+		i *= 0x74dcb303
+		i ^= (i & w) >> 2
+		i *= 0x9e501cc3
+		i ^= (i & w) >> 2
+		i *= 0xc860a3df
+		i &= w
+		i ^= i >> 5
+		if i < l {
+			break
+		}
+	}
+	return (i + p) % l
+}
+
+// randFloat hashes i with p into [0, 1), from the same paper as permute
+func randFloat(i, p uint32) float64 {
+	i ^= p
+	i ^= i >> 17
+	i ^= i >> 10
+	i *= 0xb36534e5
+	i ^= i >> 12
+	i ^= i >> 21
+	i *= 0x93fc4795
+	i ^= 0xdf6e307f
+	i ^= i >> 17
+	i *= 1 | p>>18
+	return float64(i) / (1 << 32)
+}
+
+// laineKarras is a hash that only lets higher bits depend on lower ones,
+// which is exactly an Owen scramble when applied to bit-reversed values
+func laineKarras(x, seed uint32) uint32 {
+	x += seed
+	x ^= x * 0x6c50b47c
+	x ^= x * 0xb82f1e52
+	x ^= x * 0xc7afe638
+	x ^= x * 0x8d22f6e6
+	return x
+}
+
+// nestedUniformScramble performs the Owen scramble of x, see Burley,
+// "Practical Hash-based Owen Scrambling"
+func nestedUniformScramble(x, seed uint32) uint32 {
+	return bits.Reverse32(laineKarras(bits.Reverse32(x), seed))
+}
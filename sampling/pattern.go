@@ -0,0 +1,242 @@
+package sampling
+
+import "math"
+
+// strata splits spp into an nx * ny grid that is as square as possible
+func strata(spp int) (nx, ny int) {
+	if spp < 1 {
+		spp = 1
+	}
+	nx = int(math.Ceil(math.Sqrt(float64(spp))))
+	ny = (spp + nx - 1) / nx
+	return
+}
+
+// ========================= Stratified =========================
+
+// Stratified jitters every sample inside its own stratum, the strata are
+// shuffled per pixel and per dimension so dimensions stay uncorrelated
+type Stratified struct {
+	state
+	spp    int
+	nx, ny int
+}
+
+// NewStratified creates a stratified jittered pattern for spp samples
+func NewStratified(spp int, seed int64) *Stratified {
+	nx, ny := strata(spp)
+	return &Stratified{state: state{seed: uint64(seed)}, spp: nx * ny, nx: nx, ny: ny}
+}
+
+func (p *Stratified) StartPixelSample(x, y, index int) {
+	p.start(x, y, index)
+}
+
+func (p *Stratified) Clone() Pattern {
+	c := *p
+	return &c
+}
+
+func (p *Stratified) Get1D() float64 {
+	return p.stratified1D(p.spp)
+}
+
+func (p *Stratified) Get2D() (float64, float64) {
+	n := p.nx * p.ny
+	round := p.index / n
+	s := int(permute(uint32(p.index%n), uint32(n), hash32(p.pixel, p.dim, round)))
+	u := (float64(s%p.nx) + p.random(p.dim)) / float64(p.nx)
+	v := (float64(s/p.nx) + p.random(p.dim+1)) / float64(p.ny)
+	p.dim += 2
+	return u, v
+}
+
+// ========================= Halton =========================
+
+var primes = [...]int{
+	2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53,
+	59, 61, 67, 71, 73, 79, 83, 89, 97, 101, 103, 107, 109, 113, 127, 131,
+}
+
+// Halton uses the radical inverse in successive prime bases, each pixel
+// gets its own Cranley-Patterson rotation to decorrelate neighbours.
+// Dimensions beyond the prime table fall back to independent values.
+type Halton struct {
+	state
+}
+
+// NewHalton creates a randomized Halton pattern
+func NewHalton(seed int64) *Halton {
+	return &Halton{state{seed: uint64(seed)}}
+}
+
+func (p *Halton) StartPixelSample(x, y, index int) {
+	p.start(x, y, index)
+}
+
+func (p *Halton) Clone() Pattern {
+	c := *p
+	return &c
+}
+
+func (p *Halton) Get1D() float64 {
+	var v float64
+	if p.dim < len(primes) {
+		v = radicalInverse(primes[p.dim], p.index) + p.offset(p.dim)
+		v -= math.Floor(v)
+	} else {
+		v = p.random(p.dim)
+	}
+	p.dim++
+	return v
+}
+
+func (p *Halton) Get2D() (float64, float64) {
+	return p.Get1D(), p.Get1D()
+}
+
+// radicalInverse mirrors the base-b digits of i around the decimal point
+func radicalInverse(base, i int) float64 {
+	inv := 1 / float64(base)
+	f, v := inv, 0.0
+	for ; i > 0; i /= base {
+		v += float64(i%base) * f
+		f *= inv
+	}
+	return math.Min(v, 1-1e-16)
+}
+
+// ========================= Sobol =========================
+
+// sobolMatrix holds the generator matrices of the first four Sobol
+// dimensions, built from the Joe-Kuo direction numbers
+var sobolMatrix = func() (m [4][32]uint32) {
+	params := [3]struct {
+		s, a uint32
+		m    []uint32
+	}{
+		{1, 0, []uint32{1}},
+		{2, 1, []uint32{1, 3}},
+		{3, 1, []uint32{1, 3, 1}},
+	}
+	for k := range m[0] {
+		m[0][k] = 1 << (31 - uint(k))
+	}
+	for d, pr := range params {
+		v := &m[d+1]
+		for k := uint32(0); k < pr.s; k++ {
+			v[k] = pr.m[k] << (31 - k)
+		}
+		for k := pr.s; k < 32; k++ {
+			v[k] = v[k-pr.s] ^ (v[k-pr.s] >> pr.s)
+			for j := uint32(1); j < pr.s; j++ {
+				v[k] ^= ((pr.a >> (pr.s - 1 - j)) & 1) * v[k-j]
+			}
+		}
+	}
+	return
+}()
+
+func sobol(index uint32, dim int) uint32 {
+	var x uint32
+	for k := 0; index != 0; index, k = index>>1, k+1 {
+		if index&1 != 0 {
+			x ^= sobolMatrix[dim][k]
+		}
+	}
+	return x
+}
+
+// Sobol is the Owen-scrambled Sobol sequence. Dimensions are consumed in
+// packs of four, every pack reshuffles the sample index so that packs are
+// decorrelated from each other.
+type Sobol struct {
+	state
+	pack  int
+	point [4]float64
+}
+
+// NewSobol creates an Owen-scrambled Sobol pattern
+func NewSobol(seed int64) *Sobol {
+	return &Sobol{state: state{seed: uint64(seed)}, pack: -1}
+}
+
+func (p *Sobol) StartPixelSample(x, y, index int) {
+	p.start(x, y, index)
+	p.pack = -1
+}
+
+func (p *Sobol) Clone() Pattern {
+	c := *p
+	return &c
+}
+
+func (p *Sobol) fill(pack int) {
+	seed := uint32(hash64(p.pixel ^ uint64(pack)))
+	index := nestedUniformScramble(uint32(p.index), seed)
+	for d := range p.point {
+		x := nestedUniformScramble(sobol(index, d), uint32(hash64(uint64(seed)<<8|uint64(d))))
+		p.point[d] = float64(x) / (1 << 32)
+	}
+	p.pack = pack
+}
+
+func (p *Sobol) Get1D() float64 {
+	if pack := p.dim / 4; pack != p.pack {
+		p.fill(pack)
+	}
+	v := p.point[p.dim%4]
+	p.dim++
+	return v
+}
+
+func (p *Sobol) Get2D() (float64, float64) {
+	// keep both values inside the same pack
+	if p.dim%4 == 3 {
+		p.dim++
+	}
+	return p.Get1D(), p.Get1D()
+}
+
+// ========================= CMJ =========================
+
+// CMJ is Kensler's correlated multi-jittered pattern, stratified in 2D and
+// in both 1D projections at once
+type CMJ struct {
+	state
+	spp  int
+	m, n int
+}
+
+// NewCMJ creates a correlated multi-jittered pattern for spp samples
+func NewCMJ(spp int, seed int64) *CMJ {
+	m, n := strata(spp)
+	return &CMJ{state: state{seed: uint64(seed)}, spp: m * n, m: m, n: n}
+}
+
+func (p *CMJ) StartPixelSample(x, y, index int) {
+	p.start(x, y, index)
+}
+
+func (p *CMJ) Clone() Pattern {
+	c := *p
+	return &c
+}
+
+func (p *CMJ) Get1D() float64 {
+	return p.stratified1D(p.spp)
+}
+
+func (p *CMJ) Get2D() (float64, float64) {
+	m, n := uint32(p.m), uint32(p.n)
+	seed := hash32(p.pixel, p.dim, p.index/p.spp)
+	s := permute(uint32(p.index%p.spp), m*n, seed*0x51633e2d)
+	sx := permute(s%m, m, seed*0xa511e9b3)
+	sy := permute(s/m, n, seed*0x63d83595)
+	jx := randFloat(s, seed*0xa399d265)
+	jy := randFloat(s, seed*0x711ad6a5)
+	u := (float64(s%m) + (float64(sy)+jx)/float64(n)) / float64(m)
+	v := (float64(s/m) + (float64(sx)+jy)/float64(m)) / float64(n)
+	p.dim += 2
+	return u, v
+}
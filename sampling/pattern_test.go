@@ -0,0 +1,140 @@
+package sampling
+
+import (
+	"math"
+	"testing"
+)
+
+// patterns creates every pattern of Names for spp samples per pixel
+func patterns(t *testing.T, spp int) map[string]Pattern {
+	ps := make(map[string]Pattern)
+	for _, name := range Names {
+		p, err := ByName(name, spp, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ps[name] = p
+	}
+	return ps
+}
+
+func TestPatternRange(t *testing.T) {
+	const spp = 16
+	for name, p := range patterns(t, spp) {
+		for pixel := 0; pixel < 8; pixel++ {
+			// past spp some patterns start another round
+			for index := 0; index < 3*spp; index++ {
+				p.StartPixelSample(pixel, 2*pixel+1, index)
+				for dim := 0; dim < 48; dim++ {
+					var vs []float64
+					if dim%3 == 0 {
+						vs = []float64{p.Get1D()}
+					} else {
+						u, v := p.Get2D()
+						vs = []float64{u, v}
+					}
+					for _, v := range vs {
+						if v < 0 || v >= 1 {
+							t.Fatalf("%s: pixel %d sample %d gives %g at call %d", name, pixel, index, v, dim)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestStrata checks each round of spp samples puts exactly one sample in
+// every stratum, in 1D and in 2D, and for CMJ in both projections of 2D
+func TestStrata(t *testing.T) {
+	for _, spp := range []int{1, 12, 16} {
+		nx, ny := strata(spp)
+		for name, p := range map[string]Pattern{"stratified": NewStratified(spp, 3), "cmj": NewCMJ(spp, 3)} {
+			for pixel := 0; pixel < 4; pixel++ {
+				for round := 0; round < 2; round++ {
+					strata1D := make([]int, spp)
+					strata2D := make([]int, spp)
+					projU, projV := make([]int, spp), make([]int, spp)
+					for index := round * spp; index < (round+1)*spp; index++ {
+						p.StartPixelSample(pixel, 0, index)
+						strata1D[bin(p.Get1D(), spp)]++
+						u, v := p.Get2D()
+						strata2D[bin(v, ny)*nx+bin(u, nx)]++
+						projU[bin(u, spp)]++
+						projV[bin(v, spp)]++
+					}
+					check := func(what string, counts []int) {
+						t.Helper()
+						for s, c := range counts {
+							if c != 1 {
+								t.Errorf("%s spp %d, pixel %d round %d: %s stratum %d holds %d samples",
+									name, spp, pixel, round, what, s, c)
+							}
+						}
+					}
+					check("1D", strata1D)
+					check("2D", strata2D)
+					if name == "cmj" {
+						check("u", projU)
+						check("v", projV)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	for name, p := range patterns(t, 16) {
+		p.StartPixelSample(4, 9, 5)
+		p.Get1D()
+		p.Get2D()
+		c := p.Clone()
+		want := make([]float64, 20)
+		for i := range want {
+			want[i] = p.Get1D()
+		}
+		for i, w := range want {
+			if v := c.Get1D(); v != w {
+				t.Errorf("%s: clone gives %g at dimension %d, expect %g", name, v, i+3, w)
+			}
+		}
+	}
+}
+
+// l2Discrepancy is Warnock's L2 star discrepancy of points of [0, 1)^2
+func l2Discrepancy(pts [][2]float64) float64 {
+	n := float64(len(pts))
+	var single, pairs float64
+	for _, p := range pts {
+		single += (1 - p[0]*p[0]) * (1 - p[1]*p[1]) / 4
+		for _, q := range pts {
+			pairs += (1 - math.Max(p[0], q[0])) * (1 - math.Max(p[1], q[1]))
+		}
+	}
+	return math.Sqrt(1.0/9 - 2*single/n + pairs/(n*n))
+}
+
+// TestLowDiscrepancy compares the pixel jitter, the first two dimensions,
+// of the quasi random patterns with independent samples
+func TestLowDiscrepancy(t *testing.T) {
+	const spp, pixels = 64, 32
+	mean := func(p Pattern) float64 {
+		var sum float64
+		for pixel := 0; pixel < pixels; pixel++ {
+			pts := make([][2]float64, spp)
+			for index := range pts {
+				p.StartPixelSample(pixel, 0, index)
+				pts[index][0], pts[index][1] = p.Get2D()
+			}
+			sum += l2Discrepancy(pts)
+		}
+		return sum / pixels
+	}
+	random := mean(NewIndependent(11))
+	for name, p := range map[string]Pattern{"halton": NewHalton(11), "sobol": NewSobol(11)} {
+		if d := mean(p); d > random/2 {
+			t.Errorf("%s: discrepancy %.4f, expect under half of independent samples' %.4f", name, d, random)
+		}
+	}
+}
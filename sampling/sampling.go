@@ -0,0 +1,117 @@
+package sampling
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Source supplies the successive sample dimensions consumed along a path,
+// every value lies in [0, 1)
+type Source interface {
+	Get1D() float64
+	Get2D() (float64, float64)
+}
+
+// Pattern is a pixel sampling strategy. It is positioned on one sample of
+// one pixel, then hands out dimensions in a fixed order: pixel jitter first,
+// then the lens, then every bounce.
+type Pattern interface {
+	Source
+	// StartPixelSample positions the pattern on the index-th sample of (x, y)
+	StartPixelSample(x, y, index int)
+	// Clone returns an independent copy, one is needed per worker
+	Clone() Pattern
+}
+
+// Names lists the patterns understood by ByName
+var Names = []string{"independent", "stratified", "halton", "sobol", "cmj"}
+
+// ByName creates the pattern called name, spp is the expected number of
+// samples per pixel and is only used by the stratifying patterns
+func ByName(name string, spp int, seed int64) (Pattern, error) {
+	switch strings.ToLower(name) {
+	case "independent", "random":
+		return NewIndependent(seed), nil
+	case "stratified", "jittered":
+		return NewStratified(spp, seed), nil
+	case "halton":
+		return NewHalton(seed), nil
+	case "sobol":
+		return NewSobol(seed), nil
+	case "cmj":
+		return NewCMJ(spp, seed), nil
+	}
+	return nil, fmt.Errorf("unknown sampling pattern %q, expect one of %s",
+		name, strings.Join(Names, ", "))
+}
+
+// state is shared by all patterns, it keeps track of the current pixel
+// sample and how many dimensions have been consumed so far
+type state struct {
+	seed  uint64
+	pixel uint64
+	index int
+	dim   int
+}
+
+func (st *state) start(x, y, index int) {
+	st.pixel = hash64(st.seed ^ hash64(uint64(uint32(x))|uint64(uint32(y))<<32))
+	st.index = index
+	st.dim = 0
+}
+
+// random returns a hashed uniform value for dimension dim of the current
+// pixel sample, patterns use it for jitter and for dimensions they do not
+// cover themselves
+func (st *state) random(dim int) float64 {
+	h := hash64(st.pixel ^ hash64(uint64(st.index)^uint64(dim)<<40))
+	return float64(h>>11) / (1 << 53)
+}
+
+// offset returns a per pixel value for dimension dim that stays the same
+// for every sample index, used to rotate and scramble deterministic sequences
+func (st *state) offset(dim int) float64 {
+	h := hash64(st.pixel ^ hash64(uint64(dim)+0x9e3779b97f4a7c15))
+	return float64(h>>11) / (1 << 53)
+}
+
+// stratified1D places the current sample into one of n strata, a fresh
+// permutation is drawn every n samples so that extra samples stay spread
+func (st *state) stratified1D(n int) float64 {
+	round := st.index / n
+	s := permute(uint32(st.index%n), uint32(n), hash32(st.pixel, st.dim, round))
+	v := (float64(s) + st.random(st.dim)) / float64(n)
+	st.dim++
+	return v
+}
+
+// ========================= Independent =========================
+
+// Independent draws uncorrelated uniform values, the same as calling
+// rand.Float64 for every dimension but reproducible per pixel
+type Independent struct {
+	state
+}
+
+// NewIndependent creates an independent uniform pattern
+func NewIndependent(seed int64) *Independent {
+	return &Independent{state{seed: uint64(seed)}}
+}
+
+func (p *Independent) StartPixelSample(x, y, index int) {
+	p.start(x, y, index)
+}
+
+func (p *Independent) Clone() Pattern {
+	c := *p
+	return &c
+}
+
+func (p *Independent) Get1D() float64 {
+	p.dim++
+	return p.random(p.dim - 1)
+}
+
+func (p *Independent) Get2D() (float64, float64) {
+	return p.Get1D(), p.Get1D()
+}
@@ -0,0 +1,32 @@
+package sampling
+
+import (
+	"math"
+
+	vec3 "github.com/Oaklight/Rayerson/vector"
+)
+
+// UniformSphere maps a 2D sample to a point uniformly distributed on the
+// unit sphere, it replaces rejection sampling so strata are preserved
+func UniformSphere(u1, u2 float64) *vec3.Vec3 {
+	z := 1 - 2*u1
+	r := math.Sqrt(math.Max(0, 1-z*z))
+	phi := 2 * math.Pi * u2
+	return &vec3.Vec3{X: r * math.Cos(phi), Y: r * math.Sin(phi), Z: z}
+}
+
+// ConcentricDisc maps a 2D sample to the unit disc with Shirley's
+// concentric mapping, which keeps neighbouring samples close together
+func ConcentricDisc(u1, u2 float64) (x, y float64) {
+	a, b := 2*u1-1, 2*u2-1
+	if a == 0 && b == 0 {
+		return 0, 0
+	}
+	var r, theta float64
+	if math.Abs(a) > math.Abs(b) {
+		r, theta = a, math.Pi/4*(b/a)
+	} else {
+		r, theta = b, math.Pi/2-math.Pi/4*(a/b)
+	}
+	return r * math.Cos(theta), r * math.Sin(theta)
+}
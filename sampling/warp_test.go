@@ -0,0 +1,84 @@
+package sampling
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// chiSquareLimit approximates the 99.99th percentile of the chi-square
+// distribution with df degrees of freedom (Wilson-Hilferty)
+func chiSquareLimit(df int) float64 {
+	k, z := float64(df), 3.719
+	h := 2 / (9 * k)
+	return k * math.Pow(1-h+z*math.Sqrt(h), 3)
+}
+
+func chiSquare(counts []int, expected float64) float64 {
+	var x float64
+	for _, c := range counts {
+		d := float64(c) - expected
+		x += d * d / expected
+	}
+	return x
+}
+
+// bin returns the bin of v in [0, 1) out of n
+func bin(v float64, n int) int {
+	return int(math.Max(0, math.Min(float64(n-1), v*float64(n))))
+}
+
+// TestConcentricDiscUniform splits the disc into rings of equal area and
+// equal sectors, a uniform mapping fills every cell alike
+func TestConcentricDiscUniform(t *testing.T) {
+	const n, rings, sectors = 320000, 8, 16
+	rnd := rand.New(rand.NewSource(1))
+	counts := make([]int, rings*sectors)
+	for i := 0; i < n; i++ {
+		x, y := ConcentricDisc(rnd.Float64(), rnd.Float64())
+		r2 := x*x + y*y
+		if r2 > 1+1e-12 {
+			t.Fatalf("(%g, %g) is outside the unit disc", x, y)
+		}
+		phi := (math.Atan2(y, x) + math.Pi) / (2 * math.Pi)
+		counts[bin(r2, rings)*sectors+bin(phi, sectors)]++
+	}
+	if x, limit := chiSquare(counts, n/(rings*sectors)), chiSquareLimit(rings*sectors-1); x > limit {
+		t.Errorf("not uniform: chi-square %.1f > %.1f", x, limit)
+	}
+}
+
+func TestConcentricDiscCorners(t *testing.T) {
+	cases := []struct{ u1, u2, x, y float64 }{
+		{0.5, 0.5, 0, 0},
+		{1, 0.5, 1, 0},
+		{0, 0.5, -1, 0},
+		{0.5, 1, 0, 1},
+		{0.5, 0, 0, -1},
+	}
+	for _, c := range cases {
+		x, y := ConcentricDisc(c.u1, c.u2)
+		if math.Abs(x-c.x) > 1e-12 || math.Abs(y-c.y) > 1e-12 {
+			t.Errorf("ConcentricDisc(%g, %g) = (%g, %g), want (%g, %g)", c.u1, c.u2, x, y, c.x, c.y)
+		}
+	}
+}
+
+// TestUniformSphereUniform bins z and the azimuth, both uniform on the
+// sphere, jointly
+func TestUniformSphereUniform(t *testing.T) {
+	const n, zBins, phiBins = 320000, 10, 16
+	rnd := rand.New(rand.NewSource(2))
+	counts := make([]int, zBins*phiBins)
+	for i := 0; i < n; i++ {
+		v := UniformSphere(rnd.Float64(), rnd.Float64())
+		if math.Abs(v.Length()-1) > 1e-9 {
+			t.Fatalf("length %g", v.Length())
+		}
+		phi := (math.Atan2(v.Y, v.X) + math.Pi) / (2 * math.Pi)
+		counts[bin((v.Z+1)/2, zBins)*phiBins+bin(phi, phiBins)]++
+	}
+	if x, limit := chiSquare(counts, n/(zBins*phiBins)), chiSquareLimit(zBins*phiBins-1); x > limit {
+		t.Errorf("not uniform: chi-square %.1f > %.1f", x, limit)
+	}
+}
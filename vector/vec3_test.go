@@ -0,0 +1,177 @@
+package vector
+
+import (
+	"math"
+	"testing"
+)
+
+const eps = 1e-12
+
+func near(a, b *Vec3, tol float64) bool {
+	return math.Abs(a.X-b.X) <= tol && math.Abs(a.Y-b.Y) <= tol && math.Abs(a.Z-b.Z) <= tol
+}
+
+func TestArithmetic(t *testing.T) {
+	a, b, c := &Vec3{1, 2, 3}, &Vec3{-4, 5, 0.5}, &Vec3{0, 0, 1}
+	cases := []struct {
+		name      string
+		got, want *Vec3
+	}{
+		{"Add", a.Add(b, c), &Vec3{-3, 7, 4.5}},
+		{"Add none", a.Add(), a},
+		{"Sub", a.Sub(b, c), &Vec3{5, -3, 1.5}},
+		{"Mul", a.Mul(b), &Vec3{-4, 10, 1.5}},
+		{"Div", a.Div(&Vec3{2, 4, -3}), &Vec3{0.5, 0.5, -1}},
+		{"MulScalar", a.MulScalar(-2), &Vec3{-2, -4, -6}},
+		{"DivScalar", a.DivScalar(2), &Vec3{0.5, 1, 1.5}},
+		{"Negate", a.Negate(), &Vec3{-1, -2, -3}},
+		{"Abs", b.Abs(), &Vec3{4, 5, 0.5}},
+		{"Cross", (&Vec3{1, 0, 0}).Cross(&Vec3{0, 1, 0}), &Vec3{0, 0, 1}},
+		{"Cross anticommutes", b.Cross(a), a.Cross(b).Negate()},
+		{"Normalize", (&Vec3{3, 0, 4}).Normalize(), &Vec3{0.6, 0, 0.8}},
+	}
+	for _, c := range cases {
+		if !near(c.got, c.want, eps) {
+			t.Errorf("%s: got %v, want %v", c.name, *c.got, *c.want)
+		}
+	}
+	if *a != (Vec3{1, 2, 3}) {
+		t.Errorf("operations modified their receiver: %v", *a)
+	}
+}
+
+func TestProducts(t *testing.T) {
+	a, b := &Vec3{1, 2, 3}, &Vec3{-4, 5, 0.5}
+	if got := a.Dot(b); got != 7.5 {
+		t.Errorf("Dot: got %g, want 7.5", got)
+	}
+	if got := a.Cross(b); math.Abs(got.Dot(a)) > eps || math.Abs(got.Dot(b)) > eps {
+		t.Errorf("Cross %v is not orthogonal to its operands", *got)
+	}
+	if got := (&Vec3{3, -4, 0}).Length(); got != 5 {
+		t.Errorf("Length: got %g, want 5", got)
+	}
+	if got := (&Vec3{3, -4, 0}).LengthN(1); got != 7 {
+		t.Errorf("LengthN(1): got %g, want 7", got)
+	}
+	if got := (&Vec3{3, -4, 0}).LengthN(2); got != 5 {
+		t.Errorf("LengthN(2): got %g, want 5", got)
+	}
+}
+
+func TestReflect(t *testing.T) {
+	n := &Vec3{0, 1, 0}
+	cases := []struct {
+		name    string
+		v, want *Vec3
+	}{
+		{"oblique", &Vec3{1, -1, 0}, &Vec3{1, 1, 0}},
+		{"normal incidence", &Vec3{0, -2, 0}, &Vec3{0, 2, 0}},
+		{"grazing", &Vec3{1, 0, 0}, &Vec3{1, 0, 0}},
+		{"from below", &Vec3{0.5, 1, 0}, &Vec3{0.5, -1, 0}},
+	}
+	for _, c := range cases {
+		got := c.v.Reflect(n)
+		if !near(got, c.want, eps) {
+			t.Errorf("%s: got %v, want %v", c.name, *got, *c.want)
+		}
+		if math.Abs(got.Length()-c.v.Length()) > eps {
+			t.Errorf("%s: length changed from %g to %g", c.name, c.v.Length(), got.Length())
+		}
+	}
+}
+
+func TestRefract(t *testing.T) {
+	n := &Vec3{0, 1, 0}
+	// straight through at normal incidence, whatever the ratio
+	if got := (&Vec3{0, -1, 0}).Refract(n, 1/1.5); !near(got, &Vec3{0, -1, 0}, eps) {
+		t.Errorf("normal incidence: got %v", *got)
+	}
+	// equal indices leave the direction unchanged
+	v := (&Vec3{1, -2, 0.5}).Normalize()
+	if got := v.Refract(n, 1); !near(got, v, eps) {
+		t.Errorf("ratio 1: got %v, want %v", *got, *v)
+	}
+
+	// Snell's law, sin(t) = ratio * sin(i), for unit vectors
+	for _, ratio := range []float64{1 / 1.5, 1.5} {
+		for _, deg := range []float64{10, 30, 40} {
+			i := deg * math.Pi / 180
+			v := &Vec3{math.Sin(i), -math.Cos(i), 0}
+			got := v.Refract(n, ratio)
+			if got == nil {
+				t.Errorf("ratio %g, %g degrees: unexpected total internal reflection", ratio, deg)
+				continue
+			}
+			if math.Abs(got.Length()-1) > 1e-9 {
+				t.Errorf("ratio %g, %g degrees: refracted length %g", ratio, deg, got.Length())
+			}
+			if sin := got.X; math.Abs(sin-ratio*math.Sin(i)) > 1e-9 {
+				t.Errorf("ratio %g, %g degrees: sin %g, want %g", ratio, deg, sin, ratio*math.Sin(i))
+			}
+			if got.Y >= 0 {
+				t.Errorf("ratio %g, %g degrees: %v does not cross the surface", ratio, deg, *got)
+			}
+		}
+	}
+
+	// beyond the critical angle of glass to air, 41.8 degrees
+	critical := math.Asin(1 / 1.5)
+	for _, i := range []float64{critical + 1e-6, 60 * math.Pi / 180, math.Pi / 2} {
+		v := &Vec3{math.Sin(i), -math.Cos(i), 0}
+		if got := v.Refract(n, 1.5); got != nil {
+			t.Errorf("%g degrees: got %v, want total internal reflection", i*180/math.Pi, *got)
+		}
+	}
+	// just below it the ray leaves along the surface
+	i := critical - 1e-9
+	if got := (&Vec3{math.Sin(i), -math.Cos(i), 0}).Refract(n, 1.5); got == nil || math.Abs(got.X-1) > 1e-4 {
+		t.Errorf("critical angle: got %v, want a grazing ray", got)
+	}
+}
+
+// chiSquareLimit approximates the 99.99th percentile of the chi-square
+// distribution with df degrees of freedom (Wilson-Hilferty)
+func chiSquareLimit(df int) float64 {
+	k, z := float64(df), 3.719
+	h := 2 / (9 * k)
+	return k * math.Pow(1-h+z*math.Sqrt(h), 3)
+}
+
+func chiSquare(counts []int, expected float64) float64 {
+	var x float64
+	for _, c := range counts {
+		d := float64(c) - expected
+		x += d * d / expected
+	}
+	return x
+}
+
+// TestRandUnitVec3Uniform checks the directions are unit and uniform over
+// the sphere: by Archimedes z is uniform in [-1, 1], and so is the azimuth
+func TestRandUnitVec3Uniform(t *testing.T) {
+	const n, bins = 200000, 20
+	var zs, phis [bins]int
+	var mean Vec3
+	for i := 0; i < n; i++ {
+		v := RandUnitVec3()
+		if math.Abs(v.Length()-1) > 1e-9 {
+			t.Fatalf("length %g", v.Length())
+		}
+		mean = *mean.Add(v)
+		zs[int(math.Min(bins-1, (v.Z+1)/2*bins))]++
+		phi := math.Atan2(v.Y, v.X) + math.Pi
+		phis[int(math.Min(bins-1, phi/(2*math.Pi)*bins))]++
+	}
+	limit := chiSquareLimit(bins - 1)
+	if x := chiSquare(zs[:], n/bins); x > limit {
+		t.Errorf("z is not uniform: chi-square %.1f > %.1f, %v", x, limit, zs)
+	}
+	if x := chiSquare(phis[:], n/bins); x > limit {
+		t.Errorf("azimuth is not uniform: chi-square %.1f > %.1f, %v", x, limit, phis)
+	}
+	// each coordinate has variance 1/3
+	if m := mean.DivScalar(n); m.Length() > 5*math.Sqrt(1.0/3/n)*math.Sqrt(3) {
+		t.Errorf("mean direction %v is biased", *m)
+	}
+}